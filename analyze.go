@@ -0,0 +1,155 @@
+package tinyrouter
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// An Analysis reports the worst-case lookup costs of a route table.
+// It is computed from the segment graph built by New.
+type Analysis struct {
+	Precedence     Precedence
+	NoBacktracking bool
+	Groups         []GroupAnalysis // sorted by method, kind and segment count
+}
+
+// A GroupAnalysis reports the worst-case lookup costs of a group
+// of routes with the same method and number of segments.
+type GroupAnalysis struct {
+	Method      string
+	NumSegments int
+	Remainder   bool // whether or not the routes end with remainder wildcards
+	NumPaths    int  // the number of routes (including expanded optional ones)
+
+	// The maximum number of nested wildcard fallbacks happening
+	// in a lookup, each of which follows a failed fixed match.
+	MaxBacktrackingDepth int
+
+	// The maximum number of distinct fixed segments in a column
+	// which share the same previous segments.
+	WidestColumn int
+
+	// The number of segment groups (sharing the same previous segments)
+	// containing both fixed and wildcard segments, where lookups may
+	// fall back to wildcards.
+	WildcardFallbacks int
+
+	// An upper bound of the steps (see Config.MaxLookupSteps)
+	// spent in looking up a request path in this group.
+	WorstCaseSteps int
+}
+
+// MaxWorstCaseSteps returns the maximum WorstCaseSteps of all groups.
+func (a Analysis) MaxWorstCaseSteps() int {
+	steps := 0
+	for _, g := range a.Groups {
+		steps = max(steps, g.WorstCaseSteps)
+	}
+	return steps
+}
+
+// String formats a as a table.
+func (a Analysis) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "precedence: %s, backtracking: %v\n", a.Precedence, !a.NoBacktracking)
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tSEGMENTS\tROUTES\tBACKTRACKING DEPTH\tWIDEST COLUMN\tWILDCARD FALLBACKS\tWORST-CASE STEPS")
+	for _, g := range a.Groups {
+		method, segments := g.Method, fmt.Sprint(g.NumSegments)
+		if method == "" {
+			method = "*"
+		}
+		if g.Remainder {
+			segments += "+"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\n", method, segments, g.NumPaths,
+			g.MaxBacktrackingDepth, g.WidestColumn, g.WildcardFallbacks, g.WorstCaseSteps)
+	}
+	w.Flush()
+	return b.String()
+}
+
+// Analyze reports the worst-case lookup costs of the route table.
+func (tr *TinyRouter) Analyze() Analysis {
+	a := Analysis{Precedence: tr.precedence, NoBacktracking: tr.noBacktracking}
+	analyze := func(pathsByMethod map[string]*[maxSegmentsInPath][]*path, tables *methodTables, remainder bool) {
+		for method := range pathsByMethod {
+			for numTokens, t := range tables.get(method) {
+				if t == nil {
+					continue
+				}
+				g := GroupAnalysis{
+					Method:      method,
+					NumSegments: numTokens + 1,
+					Remainder:   remainder,
+					NumPaths:    len(t.paths),
+				}
+				g.WorstCaseSteps, g.MaxBacktrackingDepth = tr.analyzeSegments(t, 0, t.numRows, &g)
+				a.Groups = append(a.Groups, g)
+			}
+		}
+	}
+	analyze(tr.pathsByMethod, &tr.tables, false)
+	analyze(tr.remainderPathsByMethod, &tr.remainderTables, true)
+
+	sort.Slice(a.Groups, func(i, j int) bool {
+		x, y := a.Groups[i], a.Groups[j]
+		if x.Method != y.Method {
+			return x.Method < y.Method
+		}
+		if x.Remainder != y.Remainder {
+			return !x.Remainder
+		}
+		return x.NumSegments < y.NumSegments
+	})
+	return a
+}
+
+// analyzeSegments analyzes the segment group (the segments in a column
+// sharing the same previous segments) from index start to index end
+// (exclusive) in table t, and the groups following it. It returns the
+// worst-case steps and the maximum backtracking depth of looking up
+// a path in these groups, counted the same way as findHandlePath and
+// findBestPath do.
+func (tr *TinyRouter) analyzeSegments(t *segmentTable, start, end int32, g *GroupAnalysis) (steps, depth int) {
+	if start >= int32(len(t.segments)) {
+		return 0, 0
+	}
+
+	var fixedSteps, fixedDepth, wildcardSteps, wildcardDepth int
+	numFixed, hasWildcard := 0, false
+	t.eachGroup(start, end, func(first, next int32, wildcard bool) {
+		s, d := tr.analyzeSegments(t, first+t.numRows, next+t.numRows, g)
+		if wildcard {
+			hasWildcard, wildcardSteps, wildcardDepth = true, s, d
+			return
+		}
+		numFixed++
+		fixedSteps, fixedDepth = max(fixedSteps, s), max(fixedDepth, d)
+	})
+
+	g.WidestColumn = max(g.WidestColumn, numFixed)
+	if numFixed > 0 && hasWildcard {
+		g.WildcardFallbacks++
+		if !tr.noBacktracking {
+			fixedDepth++
+		}
+	}
+
+	// Each distinct fixed token costs at most one step to be scanned,
+	// or a wide group is binary searched. Checking the wildcard segment
+	// costs another step.
+	steps = numFixed + 1
+	if wideEnd := t.segments[start].wideEnd; wideEnd != 0 {
+		steps = bits.Len(uint(wideEnd-start)) + 1
+	}
+	if tr.noBacktracking {
+		steps += max(fixedSteps, wildcardSteps)
+	} else {
+		steps += fixedSteps + wildcardSteps
+	}
+	return steps, max(fixedDepth, wildcardDepth)
+}
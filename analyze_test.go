@@ -0,0 +1,90 @@
+package tinyrouter
+
+import (
+	"math/rand"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	handle := func(w http.ResponseWriter, r *http.Request) {}
+	routes := backtrackingRoutes(8, handle)
+	routes = append(routes,
+		Route{Method: "GET", Pattern: "/:q/a/a/a/a/a/a/y", HandleFunc: handle},
+		Route{Method: "POST", Pattern: "/users", HandleFunc: handle},
+		Route{Method: "POST", Pattern: "/posts", HandleFunc: handle},
+		Route{Method: "POST", Pattern: "/tags", HandleFunc: handle},
+		Route{Method: "POST", Pattern: "/:type/:id", HandleFunc: handle},
+	)
+
+	for _, noBacktracking := range []bool{false, true} {
+		router := New(Config{Routes: routes, NoBacktracking: noBacktracking})
+		a := router.Analyze()
+		if len(a.Groups) != 3 {
+			t.Fatalf("%d groups are reported, 3 are expected:\n%s", len(a.Groups), a)
+		}
+
+		get, post1, post2 := a.Groups[0], a.Groups[1], a.Groups[2]
+		if get.Method != "GET" || get.NumSegments != 8 || get.NumPaths != 1<<7+1 {
+			t.Errorf("unexpected group: %+v", get)
+		}
+		if post1.Method != "POST" || post1.NumSegments != 1 || post1.NumPaths != 3 ||
+			post1.WidestColumn != 3 || post1.WildcardFallbacks != 0 ||
+			post1.MaxBacktrackingDepth != 0 || post1.WorstCaseSteps != 4 {
+			t.Errorf("unexpected group: %+v", post1)
+		}
+		if post2.NumSegments != 2 || post2.WidestColumn != 0 || post2.WorstCaseSteps != 2 {
+			t.Errorf("unexpected group: %+v", post2)
+		}
+
+		wantDepth := 7
+		if noBacktracking {
+			wantDepth = 0
+		}
+		if get.MaxBacktrackingDepth != wantDepth || get.WidestColumn != 2 {
+			t.Errorf("unexpected group (noBacktracking: %v): %+v", noBacktracking, get)
+		}
+
+		const costly = "/a/a/a/a/a/a/a/y"
+		if steps := lookupSteps(router, costly); steps > get.WorstCaseSteps {
+			t.Errorf("%d steps are spent on %s, but the worst case is estimated as %d", steps, costly, get.WorstCaseSteps)
+		}
+		if noBacktracking && get.WorstCaseSteps > 3*8 {
+			t.Errorf("worst-case steps without backtracking: %d", get.WorstCaseSteps)
+		}
+		if a.MaxWorstCaseSteps() != get.WorstCaseSteps {
+			t.Errorf("MaxWorstCaseSteps: %d, want %d", a.MaxWorstCaseSteps(), get.WorstCaseSteps)
+		}
+
+		if s := a.String(); !strings.Contains(s, "WORST-CASE STEPS") || strings.Count(s, "\n") != 5 {
+			t.Errorf("unexpected report:\n%s", s)
+		}
+	}
+}
+
+// The estimated worst-case steps must not be exceeded by any lookup.
+func TestAnalyzeUpperBound(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		router := randomRouter(rng, i, 100)
+		a := router.Analyze()
+		for j := 0; j < 20; j++ {
+			for _, url := range fuzzURLs(randomBytes(rng, rng.Intn(6))) {
+				numTokens := strings.Count(url, "/")
+				if !router.serveMuxSyntax {
+					numTokens = min(numTokens, router.maxNumTokens)
+				}
+				bound := 0
+				for _, g := range a.Groups {
+					if g.Method == "GET" && (g.NumSegments == numTokens || g.Remainder && g.NumSegments <= numTokens) {
+						bound += g.WorstCaseSteps
+					}
+				}
+				if steps := lookupSteps(router, url); steps > bound {
+					t.Fatalf("%d steps are spent on %s, but at most %d are estimated:\n%s\n%s", steps, url, bound, a, router.DumpInfo())
+				}
+			}
+		}
+	}
+}
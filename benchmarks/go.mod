@@ -1,16 +1,23 @@
 module example.com/myapp
 
+go 1.23
+
 require (
-	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/dimfeld/httptreemux v5.0.1+incompatible // indirect
 	github.com/go-chi/chi v3.3.3+incompatible
-	github.com/gorilla/context v1.1.1 // indirect
 	github.com/gorilla/mux v1.6.2
 	github.com/julienschmidt/httprouter v0.0.0-20180715161854-348b672cd90d
-	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/stretchr/testify v1.2.2 // indirect
 	github.com/teambition/trie-mux v1.4.2
 	go101.org/tinyrouter v1.0.1
 )
 
-replace go101.org/tinyrouter => github.com/go101/tinyrouter v1.0.1
+require (
+	github.com/BurntSushi/toml v1.6.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/dimfeld/httptreemux v5.0.1+incompatible // indirect
+	github.com/gorilla/context v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/stretchr/testify v1.2.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace go101.org/tinyrouter => ../
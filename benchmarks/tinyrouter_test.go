@@ -0,0 +1,256 @@
+package tinyrouter
+
+import "net/http"
+import "net/http/httptest"
+import "runtime"
+import "strconv"
+import "strings"
+import "testing"
+
+import TinyRouter "go101.org/tinyrouter"
+
+// Benchmarks for the options specific to TinyRouter.
+
+func handlerTinyRouterPathValue(w http.ResponseWriter, req *http.Request) {
+	_, _, _ = req.PathValue("param0"), req.PathValue("param1"), req.PathValue("param2")
+	w.WriteHeader(http.StatusOK)
+}
+
+func handleTinyRouter(w http.ResponseWriter, req *http.Request, params TinyRouter.Params) {
+	_, _, _ = params.Value("param0"), params.Value("param1"), params.Value("param2")
+	w.WriteHeader(http.StatusOK)
+}
+
+var tinyRouterPathValue, tinyRouterHandle *TinyRouter.TinyRouter
+
+func init() {
+	routes := make([]TinyRouter.Route, 0, len(requestPatterns))
+	for _, pattern := range requestPatterns {
+		routes = append(routes, TinyRouter.Route{
+			Method:     "GET",
+			Pattern:    pattern,
+			HandleFunc: handlerTinyRouterPathValue,
+		})
+	}
+	tinyRouterPathValue = TinyRouter.New(TinyRouter.Config{Routes: routes, SetPathValues: true})
+
+	routes = make([]TinyRouter.Route, 0, len(requestPatterns))
+	for _, pattern := range requestPatterns {
+		routes = append(routes, TinyRouter.Route{
+			Method:  "GET",
+			Pattern: pattern,
+			Handle:  handleTinyRouter,
+		})
+	}
+	tinyRouterHandle = TinyRouter.New(TinyRouter.Config{Routes: routes})
+}
+
+// Compare with Benchmark_TinyRouter_Void, which only uses request context.
+func Benchmark_TinyRouter_PathValue_Void(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, req := range requests {
+			handle(&VoidResponseWriter{}, req, tinyRouterPathValue)
+		}
+	}
+}
+
+// Route.Handle receives parameters without allocations.
+// The response writer is shared to not count its allocation.
+func Benchmark_TinyRouter_Handle_Void(b *testing.B) {
+	b.ReportAllocs()
+	w := &VoidResponseWriter{}
+	for i := 0; i < b.N; i++ {
+		for _, req := range requests {
+			handle(w, req, tinyRouterHandle)
+		}
+	}
+}
+
+// The worst case for backtracking: the routes combine fixed segment "a"
+// and wildcard segments in the first 9 columns, with a fixed segment
+// "z" in the last column. The request tries all the 512 routes.
+var worstCaseRequest = httptest.NewRequest("GET", "http://example.com/a/a/a/a/a/a/a/a/a/y", nil)
+
+func worstCaseRouter(c TinyRouter.Config) *TinyRouter.TinyRouter {
+	const n = 10
+	for bits := 0; bits < 1<<(n-1); bits++ {
+		var b strings.Builder
+		for i := 0; i < n-1; i++ {
+			if bits&(1<<i) != 0 {
+				b.WriteString("/:p" + strconv.Itoa(i))
+			} else {
+				b.WriteString("/a")
+			}
+		}
+		b.WriteString("/z")
+		c.Routes = append(c.Routes, TinyRouter.Route{
+			Method:     "GET",
+			Pattern:    b.String(),
+			HandleFunc: handlerTinyRouter(write0bytes),
+		})
+	}
+	return TinyRouter.New(c)
+}
+
+var tinyRouterWorstCase = worstCaseRouter(TinyRouter.Config{})
+var tinyRouterWorstCaseBudget = worstCaseRouter(TinyRouter.Config{MaxLookupSteps: 64})
+var tinyRouterWorstCaseNoBacktracking = worstCaseRouter(TinyRouter.Config{NoBacktracking: true})
+
+func Benchmark_TinyRouter_WorstCase_Unbounded(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		handle(&VoidResponseWriter{}, worstCaseRequest, tinyRouterWorstCase)
+	}
+}
+
+func Benchmark_TinyRouter_WorstCase_MaxLookupSteps(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		handle(&VoidResponseWriter{}, worstCaseRequest, tinyRouterWorstCaseBudget)
+	}
+}
+
+func Benchmark_TinyRouter_WorstCase_NoBacktracking(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		handle(&VoidResponseWriter{}, worstCaseRequest, tinyRouterWorstCaseNoBacktracking)
+	}
+}
+
+// A large route table, with 3000 routes in 15 groups (by the number
+// of segments), and requests spread over it.
+var largeTableRoutes, largeTableRequests = func() ([]TinyRouter.Route, []*http.Request) {
+	var routes []TinyRouter.Route
+	var requests []*http.Request
+	for v := 0; v < 3; v++ {
+		for r := 0; r < 100; r++ {
+			resources := "/api/v" + strconv.Itoa(v) + "/resource" + strconv.Itoa(r)
+			for s := 0; s < 10; s++ {
+				pattern, url := resources, resources
+				for k := 0; k < s%5; k++ {
+					pattern += "/:p" + strconv.Itoa(k)
+					url += "/x" + strconv.Itoa(k)
+				}
+				pattern += "/action" + strconv.Itoa(s)
+				url += "/action" + strconv.Itoa(s)
+				routes = append(routes, TinyRouter.Route{
+					Method:  "GET",
+					Pattern: pattern,
+					Handle:  handleTinyRouter,
+				})
+				if r%10 == v {
+					requests = append(requests, httptest.NewRequest("GET", url, nil))
+				}
+			}
+		}
+	}
+	return routes, requests
+}()
+
+var tinyRouterLargeTable = TinyRouter.New(TinyRouter.Config{Routes: largeTableRoutes})
+
+func Benchmark_TinyRouter_LargeTable_Lookup(b *testing.B) {
+	w := &VoidResponseWriter{}
+	for i := 0; i < b.N; i++ {
+		for _, req := range largeTableRequests {
+			handle(w, req, tinyRouterLargeTable)
+		}
+	}
+}
+
+// Reports the heap memory held by a router with the large table.
+func Benchmark_TinyRouter_LargeTable_Memory(b *testing.B) {
+	var routers []*TinyRouter.TinyRouter
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for i := 0; i < b.N; i++ {
+		routers = append(routers, TinyRouter.New(TinyRouter.Config{Routes: largeTableRoutes}))
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/float64(b.N), "heap-B/router")
+	runtime.KeepAlive(routers)
+}
+
+// Routes with n sibling fixed tokens in the second column, like
+// "/v1/<resource>/:id", and requests spread over them.
+func siblingsRouter(n int) (*TinyRouter.TinyRouter, []*http.Request) {
+	var routes []TinyRouter.Route
+	var requests []*http.Request
+	for i := 0; i < n; i++ {
+		resource := "resource" + strconv.Itoa(i*7919%100000)
+		routes = append(routes, TinyRouter.Route{
+			Method:  "GET",
+			Pattern: "/v1/" + resource + "/:id",
+			Handle:  handleTinyRouter,
+		})
+		if i%(n/10) == 0 {
+			requests = append(requests, httptest.NewRequest("GET", "/v1/"+resource+"/123", nil))
+		}
+	}
+	return TinyRouter.New(TinyRouter.Config{Routes: routes}), requests
+}
+
+func benchmarkSiblings(b *testing.B, n int) {
+	router, requests := siblingsRouter(n)
+	w := &VoidResponseWriter{}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, req := range requests {
+			handle(w, req, router)
+		}
+	}
+}
+
+func Benchmark_TinyRouter_Siblings_10(b *testing.B)   { benchmarkSiblings(b, 10) }
+func Benchmark_TinyRouter_Siblings_100(b *testing.B)  { benchmarkSiblings(b, 100) }
+func Benchmark_TinyRouter_Siblings_1000(b *testing.B) { benchmarkSiblings(b, 1000) }
+
+// Looks up the routes for the requests, without serving them.
+func Benchmark_TinyRouter_Lookup(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, req := range requests {
+			tinyRouterHandle.Lookup(req.Method, req.URL.Path)
+		}
+	}
+}
+
+// A hot request which is matched by the last route, after trying
+// all the 512 routes of worstCaseRouter, with and without a cache.
+var deepFallbackRequest = httptest.NewRequest("GET", "http://example.com/a/a/a/a/a/a/a/a/a/y", nil)
+
+func deepFallbackRouter(cacheSize int) *TinyRouter.TinyRouter {
+	return worstCaseRouter(TinyRouter.Config{LookupCacheSize: cacheSize, Routes: []TinyRouter.Route{{
+		Method:  "GET",
+		Pattern: "/:q/a/a/a/a/a/a/a/a/y",
+		Handle:  handleTinyRouter,
+	}}})
+}
+
+func Benchmark_TinyRouter_DeepFallback(b *testing.B) {
+	router, w := deepFallbackRouter(0), &VoidResponseWriter{}
+	for i := 0; i < b.N; i++ {
+		handle(w, deepFallbackRequest, router)
+	}
+}
+
+func Benchmark_TinyRouter_DeepFallback_Cached(b *testing.B) {
+	router, w := deepFallbackRouter(1024), &VoidResponseWriter{}
+	for i := 0; i < b.N; i++ {
+		handle(w, deepFallbackRequest, router)
+	}
+}
+
+// The cache doesn't help requests to cheap routes much.
+func Benchmark_TinyRouter_Handle_Void_Cached(b *testing.B) {
+	routes := make([]TinyRouter.Route, 0, len(requestPatterns))
+	for _, pattern := range requestPatterns {
+		routes = append(routes, TinyRouter.Route{Method: "GET", Pattern: pattern, Handle: handleTinyRouter})
+	}
+	router := TinyRouter.New(TinyRouter.Config{Routes: routes, LookupCacheSize: 1024})
+	w := &VoidResponseWriter{}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, req := range requests {
+			handle(w, req, router)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package tinyrouter
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrMissingParam is reported (wrapped in a *BindError) by Params.Bind
+// for absent parameters which are bound to required fields.
+var ErrMissingParam = errors.New("missing required parameter")
+
+// A BindError is returned by Params.Bind if a parameter
+// can't be bound to the corresponding struct field.
+type BindError struct {
+	Param   string // the parameter name
+	Pattern string // the pattern of the matched route
+	Err     error
+}
+
+func (e *BindError) Error() string {
+	return "tinyrouter: binding parameter [" + e.Param + "] of route " + e.Pattern + ": " + e.Err.Error()
+}
+
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Bind stores the parameter values into the fields of the struct
+// which dst points to. Only the fields tagged with `path:"name"` are
+// bound. Fields tagged with `path:"name,required"` must have the
+// corresponding parameters present, otherwise the fields are left
+// unchanged when the parameters are absent.
+//
+// Supported field types are string, bool, integer and floating-point
+// types, time.Duration, the types whose pointers implement
+// encoding.TextUnmarshaler, and pointers to them.
+//
+// The first error is returned as a *BindError if a value
+// can't be converted to the type of its field.
+func (p Params) Bind(dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("tinyrouter: Bind needs a non-nil pointer to struct")
+	}
+	v = v.Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("path")
+		if !ok || tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		required := false
+		switch options {
+		case "":
+		case "required":
+			required = true
+		default:
+			return &BindError{name, p.Pattern(), fmt.Errorf("unknown tag option %q of field %s", options, field.Name)}
+		}
+		if !field.IsExported() {
+			return &BindError{name, p.Pattern(), fmt.Errorf("field %s is not exported", field.Name)}
+		}
+
+		value, found := p.Lookup(name)
+		if !found {
+			if required {
+				return &BindError{name, p.Pattern(), ErrMissingParam}
+			}
+			continue
+		}
+		if err := setField(v.Field(i), value); err != nil {
+			return &BindError{name, p.Pattern(), err}
+		}
+	}
+	return nil
+}
+
+func setField(v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := setField(elem.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(elem)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
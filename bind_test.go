@@ -0,0 +1,74 @@
+package tinyrouter
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParamsBind(t *testing.T) {
+	type query struct {
+		Org     string        `path:"org,required"`
+		ID      int64         `path:"id"`
+		Shard   uint8         `path:"shard"`
+		Verbose bool          `path:"verbose"`
+		TTL     time.Duration `path:"ttl"`
+		Addr    netip.Addr    `path:"addr"`
+		Page    *int          `path:"page"`
+		Limit   int           `path:"limit"`
+		Ignored string
+	}
+
+	var params Params
+	router := New(Config{Routes: []Route{{
+		Method:  "GET",
+		Pattern: "/:org/:id/:shard/:verbose/:ttl/:addr/:page?",
+		HandleFunc: func(w http.ResponseWriter, r *http.Request) {
+			params = PathParams(r)
+		},
+	}}})
+	lookup := func(urlPath string) Params {
+		params = Params{}
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", urlPath, nil))
+		return params
+	}
+
+	q := query{Limit: 10}
+	if err := lookup("/go101/-42/7/true/1m30s/10.0.0.1/3").Bind(&q); err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+	if q.Org != "go101" || q.ID != -42 || q.Shard != 7 || !q.Verbose || q.TTL != 90*time.Second ||
+		q.Addr != netip.MustParseAddr("10.0.0.1") || q.Page == nil || *q.Page != 3 || q.Limit != 10 {
+		t.Errorf("Bind: got %+v", q)
+	}
+
+	q = query{}
+	if err := lookup("/go101/1/2/false/1s/::1").Bind(&q); err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+	if q.Page != nil {
+		t.Errorf("Bind: Page should be left nil for the omitted parameter")
+	}
+
+	err := lookup("/go101/1/256/false/1s/::1").Bind(&q)
+	var be *BindError
+	if !errors.As(err, &be) || be.Param != "shard" || be.Pattern != "/:org/:id/:shard/:verbose/:ttl/:addr/:page?" {
+		t.Errorf("Bind: unexpected error %v", err)
+	}
+
+	var missing struct {
+		User string `path:"user,required"`
+	}
+	err = lookup("/go101/1/2/false/1s/::1").Bind(&missing)
+	if !errors.Is(err, ErrMissingParam) || !strings.Contains(err.Error(), "[user]") {
+		t.Errorf("Bind: unexpected error %v", err)
+	}
+
+	if err := lookup("/go101/1/2/false/1s/::1").Bind(q); err == nil {
+		t.Errorf("Bind should fail for non-pointer values")
+	}
+}
@@ -0,0 +1,244 @@
+package tinyrouter
+
+import (
+	"hash/maphash"
+	"math/bits"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// CacheStats reports the usage of the lookup cache of a router.
+// See Config.LookupCacheSize.
+type CacheStats struct {
+	Hits, Misses uint64
+	Entries      int // the number of cached lookup results
+	Size         int // the maximum number of cached lookup results
+}
+
+// CacheStats returns the usage of the lookup cache.
+// All the numbers are zero if the cache is not enabled.
+func (tr *TinyRouter) CacheStats() CacheStats {
+	if tr.cache == nil {
+		return CacheStats{}
+	}
+	return tr.cache.stats()
+}
+
+// A lookupCache remembers the paths matching the most recently
+// requested (method, URL path) pairs, along with the token offsets in
+// the URL paths, so that the lookups for hot requests skip tokenizing the
+// paths and walking the segment tables. Only successful lookups are
+// cached, so requests matching no routes can't evict the hot ones.
+//
+// The entries are split into shards by the hashes of their keys, each of
+// which has its own lock, and evicted by the clock (second chance)
+// algorithm: a hit only sets the referenced bit of its entry under the
+// read lock of the shard, and a put evicts the first entry which is not
+// referenced since the clock hand passed it last time. The keys and the
+// token offsets are copied into the buffers of the entries, which are
+// reused by the later entries, so caching a new result doesn't allocate
+// once the buffers are large enough.
+//
+// A router never changes its routes after it is created, so the cached
+// results never get stale. A new table comes with a new router, which
+// has its own cache.
+//
+// A lookupCache is safe for concurrent use.
+type lookupCache struct {
+	size   int
+	seed   maphash.Seed
+	shards []cacheShard
+	shift  uint // the shard of a key is selected by hash >> shift
+}
+
+type cacheShard struct {
+	mu      sync.RWMutex
+	entries []cacheEntry // filled up to the capacity of the shard
+
+	// An open addressing hash table (with linear probing) of the
+	// entries, holding their indexes plus one, or zero for empty slots.
+	// Its length is a power of two and at least twice the capacity.
+	slots []int32
+
+	hand int // the clock hand, the index of the next entry to check
+
+	hits, misses atomic.Uint64
+}
+
+type cacheEntry struct {
+	hash      uint64
+	key       []byte // the method followed by the URL path
+	methodLen int
+	path      *path
+
+	// The offsets where the tokens (except the last one) end.
+	ends []int32
+
+	// Whether or not the entry is hit since the clock hand passed it.
+	referenced atomic.Bool
+}
+
+// Each shard holds at least minShardSize entries, so that the small
+// caches are close to LRU ones.
+const minShardSize = 64
+
+func newLookupCache(size int) *lookupCache {
+	// More shards than the processors lower the chance of lock contention.
+	numShards := 1
+	for numShards < 4*runtime.GOMAXPROCS(0) && size/(numShards*2) >= minShardSize {
+		numShards *= 2
+	}
+	c := &lookupCache{
+		size:   size,
+		seed:   maphash.MakeSeed(),
+		shards: make([]cacheShard, numShards),
+		shift:  uint(64 - bits.TrailingZeros(uint(numShards))),
+	}
+	for i := range c.shards {
+		n := size / numShards
+		if i < size%numShards {
+			n++
+		}
+		c.shards[i].entries = make([]cacheEntry, 0, n)
+		c.shards[i].slots = make([]int32, 1<<bits.Len(uint(2*n-1)))
+	}
+	return c
+}
+
+// hash returns the hash of the key (method, urlPath). The highest bits
+// select the shard, and the lowest bits select the slots in the shard.
+func (c *lookupCache) hash(method, urlPath string) uint64 {
+	return maphash.String(c.seed, urlPath) ^ bits.RotateLeft64(maphash.String(c.seed, method), 32)
+}
+
+// get returns the cached path matching urlPath (without the leading
+// slash) for method, or nil if there is none. The tokens of urlPath
+// are recorded in tokens on hits.
+func (c *lookupCache) get(method, urlPath string, tokens *pathTokens) *path {
+	hash := c.hash(method, urlPath)
+	sh := &c.shards[hash>>c.shift]
+	sh.mu.RLock()
+	var path *path
+	if e, _ := sh.find(hash, method, urlPath); e != nil {
+		if !e.referenced.Load() {
+			e.referenced.Store(true)
+		}
+		path = e.path
+		tokens.path, tokens.n = urlPath, len(e.ends)+1
+		copy(tokens.ends[:], e.ends)
+	}
+	sh.mu.RUnlock()
+
+	if path == nil {
+		sh.misses.Add(1)
+	} else {
+		sh.hits.Add(1)
+	}
+	return path
+}
+
+// put caches path as the one matching urlPath for method, with tokens
+// being the tokens of urlPath. An entry is evicted if the shard is full.
+func (c *lookupCache) put(method, urlPath string, path *path, tokens *pathTokens) {
+	hash := c.hash(method, urlPath)
+	sh := &c.shards[hash>>c.shift]
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	e, slot := sh.find(hash, method, urlPath)
+	if e != nil {
+		return // put by another goroutine
+	}
+
+	i := len(sh.entries)
+	if i < cap(sh.entries) {
+		sh.entries = sh.entries[:i+1]
+	} else {
+		i = sh.evict()
+		_, slot = sh.find(hash, method, urlPath) // the slots may be moved
+	}
+	e = &sh.entries[i]
+	e.hash, e.path = hash, path
+	// The key is copied to not retain the memory of the request.
+	e.key = append(append(e.key[:0], method...), urlPath...)
+	e.methodLen = len(method)
+	e.ends = append(e.ends[:0], tokens.ends[:tokens.n-1]...)
+	e.referenced.Store(false)
+	sh.slots[slot] = int32(i + 1)
+}
+
+// find returns the entry with the key (method, urlPath) and hash, and
+// its slot. If there is no such entry, it returns nil and the empty slot
+// where the entry would be put.
+func (sh *cacheShard) find(hash uint64, method, urlPath string) (*cacheEntry, int) {
+	mask := len(sh.slots) - 1
+	for slot := int(hash) & mask; ; slot = (slot + 1) & mask {
+		i := sh.slots[slot]
+		if i == 0 {
+			return nil, slot
+		}
+		e := &sh.entries[i-1]
+		if e.hash == hash && e.methodLen == len(method) && len(e.key) == len(method)+len(urlPath) &&
+			string(e.key[:len(method)]) == method && string(e.key[len(method):]) == urlPath {
+			return e, slot
+		}
+	}
+}
+
+// evict removes the first entry from the clock hand which is not
+// referenced since the hand passed it last time, and returns its index.
+// The hand clears the referenced bits of the entries it passes.
+func (sh *cacheShard) evict() int {
+	for {
+		i := sh.hand
+		sh.hand = (sh.hand + 1) % len(sh.entries)
+		if e := &sh.entries[i]; e.referenced.Load() {
+			e.referenced.Store(false)
+			continue
+		}
+		sh.unlink(i)
+		return i
+	}
+}
+
+// unlink removes entry i from the slots. The following entries in the
+// same probe sequence are shifted back, so that no tombstones are needed.
+func (sh *cacheShard) unlink(i int) {
+	mask := len(sh.slots) - 1
+	slot := int(sh.entries[i].hash) & mask
+	for sh.slots[slot] != int32(i+1) {
+		slot = (slot + 1) & mask
+	}
+	for {
+		sh.slots[slot] = 0
+		next := slot
+		for {
+			next = (next + 1) & mask
+			j := sh.slots[next]
+			if j == 0 {
+				return
+			}
+			// The entry may be moved to the empty slot
+			// if its home slot is not after the empty one.
+			home := int(sh.entries[j-1].hash) & mask
+			if (next-home)&mask >= (next-slot)&mask {
+				break
+			}
+		}
+		sh.slots[slot] = sh.slots[next]
+		slot = next
+	}
+}
+
+func (c *lookupCache) stats() CacheStats {
+	s := CacheStats{Size: c.size}
+	for i := range c.shards {
+		sh := &c.shards[i]
+		sh.mu.RLock()
+		s.Entries += len(sh.entries)
+		sh.mu.RUnlock()
+		s.Hits += sh.hits.Load()
+		s.Misses += sh.misses.Load()
+	}
+	return s
+}
@@ -0,0 +1,195 @@
+package tinyrouter
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestLookupCache(t *testing.T) {
+	handle := func(w http.ResponseWriter, r *http.Request) {}
+	router := New(Config{LookupCacheSize: 2, Routes: []Route{
+		{Method: "GET", Pattern: "/users/:name", HandleFunc: handle},
+		{Method: "GET", Pattern: "/users/:name/posts/:id", HandleFunc: handle},
+		{Method: "POST", Pattern: "/:any", HandleFunc: handle},
+	}})
+
+	lookup := func(method, urlPath, want string, values ...string) {
+		t.Helper()
+		p, _ := router.Lookup(method, urlPath)
+		if _, vs := p.ToMapAndSlice(); p.Pattern() != want || !slices.Equal(vs, values) {
+			t.Errorf("%s %s matched %s %q, want %s %q", method, urlPath, p.Pattern(), vs, want, values)
+		}
+	}
+	checkStats := func(hits, misses uint64, entries int) {
+		t.Helper()
+		want := CacheStats{Hits: hits, Misses: misses, Entries: entries, Size: 2}
+		if stats := router.CacheStats(); stats != want {
+			t.Errorf("CacheStats() = %+v, want %+v", stats, want)
+		}
+	}
+
+	lookup("GET", "/users/alice", "/users/:name", "alice")
+	lookup("GET", "/users/bob/posts/1", "/users/:name/posts/:id", "bob", "1")
+	checkStats(0, 2, 2)
+	lookup("GET", "/users/alice", "/users/:name", "alice")
+	checkStats(1, 2, 2)
+
+	// Requests matching no routes are not cached.
+	lookup("GET", "/a/b/c", "")
+	lookup("GET", "/a/b/c", "")
+	checkStats(1, 4, 2)
+
+	// The one not hit since it is cached, "/users/bob/posts/1", is evicted.
+	lookup("POST", "/users", "/:any", "users")
+	lookup("GET", "/users/alice", "/users/:name", "alice")
+	checkStats(2, 5, 2)
+	lookup("GET", "/users/bob/posts/1", "/users/:name/posts/:id", "bob", "1")
+	checkStats(2, 6, 2)
+
+	req := httptest.NewRequest("GET", "/users/bob/posts/1", nil)
+	if n := testing.AllocsPerRun(100, func() { router.Lookup("GET", "/users/alice") }); n != 0 {
+		t.Errorf("cached Lookup: %v allocations, want 0", n)
+	}
+	// The evicted entries are reused.
+	urls := []string{"/users/carol", "/users/david", "/users/erin0"}
+	if n := testing.AllocsPerRun(100, func() {
+		for _, url := range urls {
+			router.Lookup("GET", url)
+		}
+	}); n != 0 {
+		t.Errorf("Lookup caching new URLs: %v allocations, want 0", n)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				router.ServeHTTP(voidResponseWriter{}, req)
+				router.Lookup("GET", []string{"/users/alice", "/users/carol", "/x"}[j%3])
+			}
+		}()
+	}
+	wg.Wait()
+
+	if router := New(Config{}); router.CacheStats() != (CacheStats{}) {
+		t.Errorf("CacheStats of a router without a cache: %+v", router.CacheStats())
+	}
+}
+
+// Cached lookup results are the same as the uncached ones.
+func TestLookupCacheRandomTables(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		router := randomRouter(rng, i, 60)
+		router.cache = newLookupCache(1 + i%8)
+		urls := fuzzURLs(randomBytes(rng, 40))
+		for j := 0; j < 4*len(urls); j++ {
+			url := urls[rng.Intn(len(urls))]
+			got, gotOk := router.Lookup("GET", url)
+			want, wantOk := router.ReferenceLookup("GET", url)
+			_, gotValues := got.ToMapAndSlice()
+			_, wantValues := want.ToMapAndSlice()
+			if gotOk != wantOk || got.Pattern() != want.Pattern() || !slices.Equal(gotValues, wantValues) {
+				t.Fatalf("GET %s: Lookup: %s %q, ReferenceLookup: %s %q", url, got.Pattern(), gotValues, want.Pattern(), wantValues)
+			}
+			checkCachedTokens(t, router, url, got)
+		}
+	}
+}
+
+// checkCachedTokens checks the tokens recorded by looking up url,
+// which should be consistent with the Params matching url.
+func checkCachedTokens(t *testing.T, router *TinyRouter, url string, p Params) {
+	t.Helper()
+	var tokens pathTokens
+	if path := router.lookup("GET", url[1:], &tokens); path != p.path {
+		t.Fatalf("GET %s: lookup: %v, want %v", url, path, p.path)
+	}
+	if p.path == nil {
+		return
+	}
+	for _, seg := range p.path.wildcards {
+		if got, want := tokens.value(seg), p.value(seg); got != want {
+			t.Fatalf("GET %s: the token of %s is %q, want %q", url, seg.token, got, want)
+		}
+	}
+}
+
+// The large caches are sharded.
+func TestShardedLookupCache(t *testing.T) {
+	handle := func(w http.ResponseWriter, r *http.Request) {}
+	router := New(Config{LookupCacheSize: 300, Routes: []Route{
+		{Method: "GET", Pattern: "/:a/:b", HandleFunc: handle},
+		{Method: "GET", Pattern: "/x/:b/:c", HandleFunc: handle},
+	}})
+	if n := len(router.cache.shards); n < 2 {
+		t.Fatalf("%d shards, want more", n)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		url := fmt.Sprintf("/x/%d/%d", rng.Intn(10), rng.Intn(100))
+		if i%2 == 0 {
+			url = fmt.Sprintf("/%d/%d", rng.Intn(20), rng.Intn(20))
+		}
+		got, _ := router.Lookup("GET", url)
+		want, _ := router.ReferenceLookup("GET", url)
+		_, gotValues := got.ToMapAndSlice()
+		_, wantValues := want.ToMapAndSlice()
+		if got.Pattern() != want.Pattern() || !slices.Equal(gotValues, wantValues) {
+			t.Fatalf("GET %s: Lookup: %s %q, ReferenceLookup: %s %q", url, got.Pattern(), gotValues, want.Pattern(), wantValues)
+		}
+		checkCachedTokens(t, router, url, got)
+	}
+
+	stats := router.CacheStats()
+	if stats.Entries != 300 || stats.Hits == 0 || stats.Hits+stats.Misses != 40000 {
+		t.Errorf("CacheStats() = %+v", stats)
+	}
+	for i := range router.cache.shards {
+		sh := &router.cache.shards[i]
+		for slot, j := range sh.slots {
+			if j == 0 {
+				continue
+			}
+			e := &sh.entries[j-1]
+			if found, s := sh.find(e.hash, string(e.key[:e.methodLen]), string(e.key[e.methodLen:])); found != e || s != slot {
+				t.Fatalf("the entry of %s is not found in slot %d", e.key, slot)
+			}
+		}
+	}
+}
+
+func BenchmarkLookupCacheParallel(b *testing.B) {
+	handle := func(w http.ResponseWriter, r *http.Request) {}
+	var routes []Route
+	for i := 0; i < 50; i++ {
+		routes = append(routes,
+			Route{Method: "GET", Pattern: fmt.Sprintf("/v1/r%d/:id", i), HandleFunc: handle},
+			Route{Method: "GET", Pattern: fmt.Sprintf("/v1/r%d/:id/:action", i), HandleFunc: handle})
+	}
+	routes = append(routes, Route{Method: "GET", Pattern: "/:version/:resource/:id", HandleFunc: handle})
+	var urls []string
+	for i := 0; i < 1000; i++ {
+		urls = append(urls, fmt.Sprintf("/v%d/r%d/%d", i%2, i%60, i))
+	}
+
+	for _, size := range []int{0, 100, 10000} {
+		router := New(Config{Routes: routes, LookupCacheSize: size})
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					router.Lookup("GET", urls[i%len(urls)])
+				}
+			})
+		})
+	}
+}
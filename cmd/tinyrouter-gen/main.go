@@ -0,0 +1,67 @@
+// Tinyrouter-gen generates the Go source code of a router from a route
+// file (see tinyrouter.RouteFile), the format of which is told by its
+// extension (see tinyrouter.RouteFileFormatOf). The generated router looks
+// up routes by switch statements, and selects the same routes as a
+// TinyRouter.
+//
+// Usage:
+//
+//	tinyrouter-gen [-o output] [-pkg package] [-type name] routes.json
+//
+// It is designed to be run by go generate, with a directive like
+//
+//	//go:generate go run go101.org/tinyrouter/cmd/tinyrouter-gen -o router_gen.go routes.json
+//
+// in which case the package name defaults to the one being generated.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"go101.org/tinyrouter"
+)
+
+func main() {
+	output := flag.String("o", "", "the output file (default stdout)")
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "the package name of the generated code")
+	typeName := flag.String("type", "Router", "the type name of the generated router")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: tinyrouter-gen [-o output] [-pkg package] [-type name] routes.json")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || *pkg == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), *output, *pkg, *typeName); err != nil {
+		fmt.Fprintln(os.Stderr, "tinyrouter-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(input, output, pkg, typeName string) error {
+	f, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	routes, err := tinyrouter.ReadRouteFile(f, tinyrouter.RouteFileFormatOf(input))
+	if err != nil {
+		return fmt.Errorf("%s: %w", input, err)
+	}
+
+	var b bytes.Buffer
+	if err := tinyrouter.Generate(&b, routes, pkg, typeName); err != nil {
+		return fmt.Errorf("%s: %w", input, err)
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(b.Bytes())
+		return err
+	}
+	return os.WriteFile(output, b.Bytes(), 0o666)
+}
@@ -0,0 +1,273 @@
+// Tinyrouter is a tool working on route files (see tinyrouter.RouteFile),
+// which are in YAML or TOML if their extensions are ".yaml", ".yml" or
+// ".toml", or in JSON otherwise.
+//
+// Usage:
+//
+//	tinyrouter lint [-f routes.json] [-overlaps] [-maxsteps N]
+//	tinyrouter list [-f routes.json]
+//	tinyrouter match [-f routes.json] METHOD URL
+//	tinyrouter dump [-f routes.json] [--dot]
+//	tinyrouter diff OLD.json NEW.json
+//
+// The lint command validates the patterns and reports the routes shadowed
+// by other routes, which are unreachable (see tinyrouter.TinyRouter.Validate).
+// With -overlaps, it also reports the pairs of routes both matching some
+// request paths, which are usually intended, such as "/users/:name" and
+// "/users/new". With -maxsteps, it also reports the route groups where a
+// lookup may take more steps than N in the worst case (see
+// tinyrouter.TinyRouter.Analyze), so that CI jobs may keep the routing costs
+// in check. The exit code is 1 if the route file is invalid, or there are
+// unreachable routes or costly route groups, but not overlapping routes.
+//
+// The list command prints the routes sorted by method and pattern. The match
+// command shows the route a request would be routed to, with the parameters
+// and a trace of looking up the route. The dump command prints the segment
+// tables, or the segment graph in the DOT language of Graphviz with --dot.
+// The diff command reports the routes added, removed and modified in a new
+// route file, and the sampled requests which would be routed differently
+// (see tinyrouter.Diff). The exit code is 1 if there are differences.
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"go101.org/tinyrouter"
+)
+
+const usage = `usage:
+	tinyrouter lint [-f routes.json] [-overlaps] [-maxsteps N]
+	tinyrouter list [-f routes.json]
+	tinyrouter match [-f routes.json] METHOD URL
+	tinyrouter dump [-f routes.json] [--dot]
+	tinyrouter diff OLD.json NEW.json
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command line args, and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	fs := flag.NewFlagSet("tinyrouter "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, usage) }
+	filename := fs.String("f", "routes.json", "the route file")
+	dot, overlaps := false, false
+	maxSteps := 0
+	numArgs := 0
+	switch args[0] {
+	case "lint":
+		fs.BoolVar(&overlaps, "overlaps", false, "also report the overlapping routes")
+		fs.IntVar(&maxSteps, "maxsteps", 0, "the maximum worst-case lookup steps (0 means no limit)")
+	case "list":
+	case "match":
+		numArgs = 2
+	case "dump":
+		fs.BoolVar(&dot, "dot", false, "print the segment graph in the DOT language")
+	case "diff":
+		numArgs = 2
+	default:
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if fs.NArg() != numArgs {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if args[0] == "diff" {
+		return diff(fs.Arg(0), fs.Arg(1), stdout, stderr)
+	}
+
+	rf, err := load(*filename)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if rf.err != nil && args[0] != "lint" && args[0] != "list" {
+		fmt.Fprintln(stderr, rf.err)
+		return 1
+	}
+	switch args[0] {
+	case "lint":
+		return lint(rf, overlaps, maxSteps, stdout)
+	case "list":
+		list(rf, stdout)
+	case "match":
+		return match(rf, fs.Arg(0), fs.Arg(1), stdout, stderr)
+	case "dump":
+		if dot {
+			fmt.Fprint(stdout, rf.router.DumpDot())
+		} else {
+			fmt.Fprintln(stdout, rf.router.DumpInfo())
+		}
+	}
+	return 0
+}
+
+// A routeFile is a loaded route file.
+type routeFile struct {
+	name   string
+	file   tinyrouter.RouteFile
+	router *tinyrouter.TinyRouter
+	err    error // the error in loading the routes, if router is nil
+}
+
+// load loads the route file named filename. The handlers of the routes
+// do nothing, and are told apart by their names in the route file. The
+// errors in the routes are recorded in the returned routeFile, and the
+// returned error is for the others.
+func load(filename string) (*routeFile, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	rf := &routeFile{name: filename}
+	format := tinyrouter.RouteFileFormatOf(filename)
+	rf.file, err = tinyrouter.ReadRouteFile(bytes.NewReader(data), format)
+	if err != nil {
+		return nil, rf.errorf(err)
+	}
+
+	registry := make(map[string]http.HandlerFunc)
+	for _, d := range rf.file.Routes {
+		if d.Handler != "" {
+			registry[d.Handler] = func(http.ResponseWriter, *http.Request) {}
+		}
+	}
+	rf.router, err = tinyrouter.LoadRouter(bytes.NewReader(data), format, registry, nil)
+	if err != nil {
+		rf.err = rf.errorf(err)
+	}
+	return rf, nil
+}
+
+// errorf returns err prefixed with the file name
+// instead of the package name.
+func (rf *routeFile) errorf(err error) error {
+	return fmt.Errorf("%s: %s", rf.name, strings.TrimPrefix(err.Error(), "tinyrouter: "))
+}
+
+func lint(rf *routeFile, overlaps bool, maxSteps int, stdout io.Writer) int {
+	if rf.err != nil {
+		fmt.Fprintln(stdout, rf.err)
+		return 1
+	}
+	code := 0
+	for _, w := range rf.router.Validate() {
+		switch {
+		case w.Kind == tinyrouter.WarningUnreachable:
+			code = 1
+		case !overlaps:
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: %s\n", rf.name, w)
+	}
+	if maxSteps <= 0 {
+		return code
+	}
+	for _, g := range rf.router.Analyze().Groups {
+		if g.WorstCaseSteps <= maxSteps {
+			continue
+		}
+		method, kind := g.Method, ""
+		if method == "" {
+			method = "*"
+		}
+		if g.Remainder {
+			kind = " (remainder)"
+		}
+		fmt.Fprintf(stdout, "%s: %s routes with %d segments%s: up to %d lookup steps, more than %d\n",
+			rf.name, method, g.NumSegments, kind, g.WorstCaseSteps, maxSteps)
+		code = 1
+	}
+	return code
+}
+
+func list(rf *routeFile, stdout io.Writer) {
+	routes := slices.Clone(rf.file.Routes)
+	slices.SortStableFunc(routes, func(a, b tinyrouter.RouteDecl) int {
+		return cmp.Or(cmp.Compare(a.Method, b.Method), cmp.Compare(a.Pattern, b.Pattern))
+	})
+
+	w := tabwriter.NewWriter(stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATTERN\tNAME\tHANDLER")
+	for _, r := range routes {
+		method := r.Method
+		if method == "" {
+			method = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", method, r.Pattern, r.Name, r.Handler)
+	}
+	w.Flush()
+}
+
+func match(rf *routeFile, method, rawURL string, stdout, stderr io.Writer) int {
+	u, err := url.Parse(rawURL)
+	if err != nil || !strings.HasPrefix(u.Path, "/") {
+		fmt.Fprintf(stderr, "bad URL: %s\n", rawURL)
+		return 2
+	}
+
+	params, ok := rf.router.Lookup(method, u.Path)
+	if !ok {
+		fmt.Fprintf(stdout, "%s %s matches no routes\n", method, rawURL)
+	} else {
+		fmt.Fprintf(stdout, "route:   %s\n", params.Pattern())
+		if name := params.RouteName(); name != "" {
+			fmt.Fprintf(stdout, "name:    %s\n", name)
+		}
+		fmt.Fprintf(stdout, "handler: %s\n", rf.file.Routes[params.RouteIndex()].Handler)
+		for name, value := range params.All() {
+			fmt.Fprintf(stdout, "param:   %s=%q\n", name, value)
+		}
+	}
+
+	fmt.Fprintln(stdout, "trace:")
+	for _, line := range rf.router.Explain(method, u.Path) {
+		fmt.Fprintf(stdout, "  %s\n", line)
+	}
+	if !ok {
+		return 1
+	}
+	return 0
+}
+
+func diff(oldFilename, newFilename string, stdout, stderr io.Writer) int {
+	var routers []*tinyrouter.TinyRouter
+	for _, filename := range []string{oldFilename, newFilename} {
+		rf, err := load(filename)
+		if err == nil {
+			err = rf.err
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		routers = append(routers, rf.router)
+	}
+
+	d := tinyrouter.Diff(routers[0], routers[1])
+	fmt.Fprint(stdout, d)
+	if !d.Empty() {
+		return 1
+	}
+	return 0
+}
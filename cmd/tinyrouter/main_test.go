@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	routes := filepath.Join(dir, "routes.json")
+	if err := os.WriteFile(routes, []byte(`{
+	"routes": [
+		{"method": "GET", "pattern": "/users/:name", "handler": "getUser", "name": "user"},
+		{"method": "GET", "pattern": "/users/new", "handler": "newUser"},
+		{"method": "GET", "pattern": "/:page", "handler": "page"}
+	]
+}`), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{
+	"routes": [
+		{"method": "GET", "pattern": "/users/:name", "handler": "getUser"},
+		{"method": "GET", "pattern": "/users/:id", "handler": "getUser"}
+	]
+}`), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	wide := filepath.Join(dir, "wide.json")
+	if err := os.WriteFile(wide, []byte(`{
+	"routes": [
+		{"method": "GET", "pattern": "/a/:x", "handler": "x"},
+		{"method": "GET", "pattern": "/b/:x", "handler": "x"},
+		{"method": "GET", "pattern": "/c/:x", "handler": "x"}
+	]
+}`), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	unreachable := filepath.Join(dir, "unreachable.json")
+	if err := os.WriteFile(unreachable, []byte(`{
+	"serveMuxSyntax": true,
+	"routes": [
+		{"method": "GET", "pattern": "/{all...}", "handler": "all"},
+		{"method": "GET", "pattern": "/{a}", "handler": "a"},
+		{"method": "GET", "pattern": "/{a}/{rest...}", "handler": "rest"}
+	]
+}`), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	changed := filepath.Join(dir, "changed.yaml")
+	if err := os.WriteFile(changed, []byte(`routes:
+  - {method: GET, pattern: "/users/:name", handler: showUser, name: user}
+  - {method: GET, pattern: /users/new, handler: newUser}
+  - {method: GET, pattern: /users/me, handler: me}
+`), 0o666); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		args     []string
+		code     int
+		want     string // the output, or the first line of it if it ends with "..."
+		wantErrs string
+	}{
+		{[]string{"list", "-f", routes}, 0, `METHOD  PATTERN       NAME  HANDLER
+GET     /:page              page
+GET     /users/:name  user  getUser
+GET     /users/new          newUser
+`, ""},
+		{[]string{"lint", "-f", routes}, 0, "", ""},
+		{[]string{"lint", "-f", routes, "-overlaps"}, 0, routes + ": GET /users/:name: overlaps /users/new, both matching /users/new\n", ""},
+		{[]string{"lint", "-f", unreachable}, 1, unreachable + ": GET /{all...}: unreachable, /x is routed to /{a}\n", ""},
+		{[]string{"lint", "-f", unreachable, "-overlaps"}, 1, unreachable + `: GET /{all...}: unreachable, /x is routed to /{a}
+` + unreachable + `: GET /{all...}: overlaps /{a}, both matching /x
+` + unreachable + `: GET /{all...}: overlaps /{a}/{rest...}, both matching /x/x
+`, ""},
+		{[]string{"lint", "-f", invalid}, 1, invalid + ": line 4: Equal paths are not allowed:...", ""},
+		{[]string{"lint", "-f", wide, "-maxsteps", "4"}, 1, wide + ": GET routes with 2 segments: up to 5 lookup steps, more than 4\n", ""},
+		{[]string{"lint", "-f", wide, "-maxsteps", "5"}, 0, "", ""},
+		{[]string{"match", "-f", routes, "GET", "/users/alice?tab=repos"}, 0, `route:   /users/:name
+name:    user
+handler: getUser
+param:   name="alice"
+trace:
+  trying GET routes with 2 segments
+    "users" matches "users"
+      "alice" matches no fixed segments
+      "alice" matches :name
+      matched /users/:name
+  selected /users/:name
+`, ""},
+		{[]string{"match", "-f", unreachable, "HEAD", "/x/y"}, 0, `route:   /{a}/{rest...}
+handler: rest
+param:   a="x"
+param:   rest="y"
+trace:...`, ""},
+		{[]string{"match", "-f", routes, "POST", "/a/b"}, 1, `POST /a/b matches no routes
+trace:
+  no POST routes
+  no routes match
+`, ""},
+		{[]string{"match", "-f", invalid, "GET", "/"}, 1, "", invalid + ": line 4: Equal paths are not allowed:..."},
+		{[]string{"dump", "-f", routes, "--dot"}, 0, "digraph routes {...", ""},
+		{[]string{"dump", "-f", routes}, 0, "precedence: left-to-right...", ""},
+		{[]string{"diff", routes, changed}, 1, `added    GET /users/me
+removed  GET /:page
+modified GET /users/:name [user]: HandleFunc
+rerouted GET /users/me: GET /users/:name [user] => GET /users/me
+`, ""},
+		{[]string{"diff", routes, routes}, 0, "", ""},
+		{[]string{"diff", routes, invalid}, 1, "", invalid + ": line 4: Equal paths are not allowed:..."},
+		{[]string{"match", "-f", routes, "GET"}, 2, "", "usage:..."},
+		{[]string{"lint", "-f", filepath.Join(dir, "none.json")}, 1, "", "open ..."},
+		{[]string{"format"}, 2, "", "usage:..."},
+	}
+	for _, c := range cases {
+		var stdout, stderr strings.Builder
+		code := run(c.args, &stdout, &stderr)
+		if code != c.code || !matchOutput(stdout.String(), c.want) || !matchOutput(stderr.String(), c.wantErrs) {
+			t.Errorf("tinyrouter %s: exit code %d, want %d\nstdout:\n%s\nwant:\n%s\nstderr:\n%s\nwant:\n%s",
+				strings.Join(c.args, " "), code, c.code, stdout.String(), c.want, stderr.String(), c.wantErrs)
+		}
+	}
+}
+
+func matchOutput(got, want string) bool {
+	if prefix, ok := strings.CutSuffix(want, "..."); ok {
+		return strings.HasPrefix(got, prefix)
+	}
+	return got == want
+}
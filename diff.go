@@ -0,0 +1,349 @@
+package tinyrouter
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// A RouteDiff is the difference between two routing tables. See Diff.
+type RouteDiff struct {
+	Added    []Route       // the routes only in the new table
+	Removed  []Route       // the routes only in the old table
+	Modified []RouteChange // the routes changed in the new table
+
+	// The sampled requests which are routed differently
+	// because of the changed precedences of the routes.
+	Rerouted []PrecedenceChange
+}
+
+// A RouteChange is a route changed between two routing tables.
+type RouteChange struct {
+	Old, New Route
+
+	// The names of the changed fields of Route, such as "Name".
+	Fields []string
+}
+
+// A PrecedenceChange is a request which is routed differently by two
+// routing tables. Old or New is the zero Route if no routes match.
+type PrecedenceChange struct {
+	Method, URL string
+	Old, New    Route
+}
+
+// Diff returns the difference between the routing tables of old and new,
+// for reviewing routing changes before deploying them.
+//
+// Routes are identified by their methods and patterns. The routes which
+// are not found in the other table are then paired by their names, so
+// that a renamed pattern is reported as a modification. The handlers are
+// compared by their names in the route files if both routers are loaded by
+// LoadRouter (or a Reloader), or else by their code, so closures created
+// by the same function literal are not told apart.
+//
+// The precedence changes are found by sampling request paths from the
+// segment graphs of both routers. For each route, a request path matching
+// it is sampled with the parameters replaced by a token matching no fixed
+// segments, and with each parameter (one at a time) replaced by the fixed
+// tokens it competes with. A request is reported if it is routed to
+// different routes by the two routers, unless it is only because it now
+// matches an added route or it no longer matches a removed route. In the
+// syntax of http.ServeMux, a HEAD request is only reported if it is routed
+// differently from the GET request with the same path.
+func Diff(old, new *TinyRouter) RouteDiff {
+	var d RouteDiff
+	oldRoutes, newRoutes := routesByKey(old), routesByKey(new)
+
+	var removed []int
+	for i, r := range old.routes {
+		j, ok := newRoutes[keyOf(r)]
+		if !ok {
+			removed = append(removed, i)
+		} else if fields := changedFields(old, new, i, j); len(fields) > 0 {
+			d.Modified = append(d.Modified, RouteChange{r, new.routes[j], fields})
+		}
+	}
+	renamed := make(map[string]int)
+	for j, r := range new.routes {
+		if _, ok := oldRoutes[keyOf(r)]; ok {
+			continue
+		}
+		if r.Name != "" {
+			renamed[r.Name] = j
+		} else {
+			d.Added = append(d.Added, r)
+		}
+	}
+	for _, i := range removed {
+		r := old.routes[i]
+		if j, ok := renamed[r.Name]; ok && r.Name != "" {
+			d.Modified = append(d.Modified, RouteChange{r, new.routes[j], changedFields(old, new, i, j)})
+			delete(renamed, r.Name)
+		} else {
+			d.Removed = append(d.Removed, r)
+		}
+	}
+	for _, j := range renamed {
+		d.Added = append(d.Added, new.routes[j])
+	}
+
+	compareRoutes := func(a, b Route) int {
+		return cmp.Or(cmp.Compare(a.Method, b.Method), cmp.Compare(a.Pattern, b.Pattern))
+	}
+	slices.SortFunc(d.Added, compareRoutes)
+	slices.SortFunc(d.Removed, compareRoutes)
+	slices.SortFunc(d.Modified, func(a, b RouteChange) int {
+		return compareRoutes(a.New, b.New)
+	})
+
+	d.Rerouted = diffPrecedences(old, new, oldRoutes, newRoutes)
+	return d
+}
+
+// Empty reports whether there are no differences.
+func (d RouteDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0 && len(d.Rerouted) == 0
+}
+
+// String returns the differences, one per line.
+func (d RouteDiff) String() string {
+	var b strings.Builder
+	for _, r := range d.Added {
+		fmt.Fprintf(&b, "added    %s\n", routeString(r))
+	}
+	for _, r := range d.Removed {
+		fmt.Fprintf(&b, "removed  %s\n", routeString(r))
+	}
+	for _, c := range d.Modified {
+		route := routeString(c.New)
+		if keyOf(c.Old) != keyOf(c.New) {
+			route = routeString(c.Old) + " => " + route
+		}
+		fmt.Fprintf(&b, "modified %s: %s\n", route, strings.Join(c.Fields, ", "))
+	}
+	for _, c := range d.Rerouted {
+		fmt.Fprintf(&b, "rerouted %s %s: %s => %s\n", methodName(c.Method), c.URL, routeString(c.Old), routeString(c.New))
+	}
+	return b.String()
+}
+
+// methodName returns method, or "*" if it is blank.
+func methodName(method string) string {
+	if method == "" {
+		return "*"
+	}
+	return method
+}
+
+func routeString(r Route) string {
+	if r.Pattern == "" {
+		return "no routes"
+	}
+	s := r.Pattern
+	if strings.HasPrefix(s, "/") { // not a ServeMux pattern with the method
+		s = methodName(r.Method) + " " + s
+	}
+	if r.Name != "" {
+		s += " [" + r.Name + "]"
+	}
+	return s
+}
+
+// A routeKey identifies a route in a routing table.
+type routeKey struct {
+	method, pattern string
+}
+
+func keyOf(r Route) routeKey {
+	return routeKey{r.Method, r.Pattern}
+}
+
+// routesByKey returns the indexes of the routes of tr by their keys.
+func routesByKey(tr *TinyRouter) map[routeKey]int {
+	routes := make(map[routeKey]int, len(tr.routes))
+	for i, r := range tr.routes {
+		routes[keyOf(r)] = i
+	}
+	return routes
+}
+
+// changedFields returns the names of the fields changed from
+// route i of old to route j of new.
+func changedFields(old, new *TinyRouter, i, j int) []string {
+	x, y := old.routes[i], new.routes[j]
+	var fields []string
+	if x.Method != y.Method {
+		fields = append(fields, "Method")
+	}
+	if x.Pattern != y.Pattern {
+		fields = append(fields, "Pattern")
+	}
+	if x.Name != y.Name {
+		fields = append(fields, "Name")
+	}
+	if !maps.Equal(x.Metadata, y.Metadata) {
+		fields = append(fields, "Metadata")
+	}
+	if oldName, newName := old.handlerName(i), new.handlerName(j); oldName != "" && newName != "" {
+		if oldName != newName {
+			fields = append(fields, "HandleFunc")
+		}
+		return fields
+	}
+	if funcPointer(x.HandleFunc) != funcPointer(y.HandleFunc) {
+		fields = append(fields, "HandleFunc")
+	}
+	if funcPointer(x.Handle) != funcPointer(y.Handle) {
+		fields = append(fields, "Handle")
+	}
+	return fields
+}
+
+// handlerName returns the name of the handler of route i in the route
+// file tr is loaded from, or "" if it is unknown.
+func (tr *TinyRouter) handlerName(i int) string {
+	if i < len(tr.handlerNames) {
+		return tr.handlerNames[i]
+	}
+	return ""
+}
+
+// funcPointer returns the code pointer of function f, or 0 if f is nil.
+func funcPointer(f any) uintptr {
+	v := reflect.ValueOf(f)
+	if v.IsNil() {
+		return 0
+	}
+	return v.Pointer()
+}
+
+// diffPrecedences returns the requests, sampled from the segment graphs of
+// old and new, which are routed differently by them, sorted by the methods
+// and then the URLs.
+func diffPrecedences(old, new *TinyRouter, oldRoutes, newRoutes map[routeKey]int) []PrecedenceChange {
+	s := &sampler{
+		placeholder: placeholderToken(old, new),
+		requests:    make(map[request]bool),
+	}
+	methods := old.methods()
+	for _, method := range new.methods() {
+		if !slices.Contains(methods, method) {
+			methods = append(methods, method)
+		}
+	}
+	for _, tr := range []*TinyRouter{old, new} {
+		for _, method := range tr.methods() {
+			s.methods = []string{method}
+			if tr.serveMuxSyntax {
+				switch method {
+				case "": // the routes may be shadowed in any method
+					s.methods = methods
+				case http.MethodGet:
+					s.methods = append(s.methods, http.MethodHead)
+				}
+			}
+			for _, tables := range []*[maxSegmentsInPath]*segmentTable{tr.tables.get(method), tr.remainderTables.get(method)} {
+				if tables == nil {
+					continue
+				}
+				for _, t := range tables {
+					if t != nil {
+						s.group(t, 0, t.numRows, nil, false)
+					}
+				}
+			}
+		}
+	}
+
+	var changes []PrecedenceChange
+	for req := range s.requests {
+		oldParams, oldOK := old.Lookup(req.method, req.url)
+		newParams, newOK := new.Lookup(req.method, req.url)
+		var c PrecedenceChange
+		if oldOK {
+			c.Old = old.routes[oldParams.path.index]
+		}
+		if newOK {
+			c.New = new.routes[newParams.path.index]
+		}
+		if keyOf(c.Old) == keyOf(c.New) {
+			continue
+		}
+		if _, ok := oldRoutes[keyOf(c.New)]; !oldOK && !ok {
+			continue // it matches an added route
+		}
+		if _, ok := newRoutes[keyOf(c.Old)]; !newOK && !ok {
+			continue // it matched a removed route
+		}
+		c.Method, c.URL = req.method, req.url
+		changes = append(changes, c)
+	}
+	// The HEAD requests routed like the GET ones are not reported twice.
+	changes = slices.DeleteFunc(changes, func(c PrecedenceChange) bool {
+		return c.Method == http.MethodHead && slices.ContainsFunc(changes, func(g PrecedenceChange) bool {
+			return g.Method == http.MethodGet && g.URL == c.URL && keyOf(g.Old) == keyOf(c.Old) && keyOf(g.New) == keyOf(c.New)
+		})
+	})
+	slices.SortFunc(changes, func(a, b PrecedenceChange) int {
+		return cmp.Or(cmp.Compare(a.Method, b.Method), cmp.Compare(a.URL, b.URL))
+	})
+	return changes
+}
+
+// A sampler samples the request paths matching the paths in segment tables.
+type sampler struct {
+	placeholder string
+	methods     []string // the methods of the sampled requests
+	requests    map[request]bool
+}
+
+type request struct {
+	method, url string
+}
+
+// group samples the segment group from index start to index end (exclusive)
+// in t and the groups following it, with tokens being the tokens of the
+// preceding columns. If varied is true, a parameter in the preceding columns
+// has taken a fixed token, and the following parameters only take the
+// placeholder.
+func (s *sampler) group(t *segmentTable, start, end int32, tokens []string, varied bool) {
+	var fixed []string
+	t.eachGroup(start, end, func(first, next int32, wildcard bool) {
+		if !wildcard {
+			token := t.segments[first].token
+			fixed = append(fixed, token)
+			s.next(t, first, next, append(tokens, token), varied)
+			return
+		}
+
+		s.next(t, first, next, append(tokens, s.placeholder), varied)
+		if !varied {
+			// The fixed tokens may make the parameters (not) matched by backtracking.
+			for _, token := range fixed {
+				s.next(t, first, next, append(tokens, token), true)
+			}
+		}
+	})
+}
+
+// next samples the segments following the ones from index start to
+// index end (exclusive) in t, which match the last one of tokens.
+func (s *sampler) next(t *segmentTable, start, end int32, tokens []string, varied bool) {
+	if start < t.lastColumn {
+		s.group(t, start+t.numRows, end+t.numRows, tokens, varied)
+		return
+	}
+	url := "/" + strings.Join(tokens, "/")
+	for _, method := range s.methods {
+		s.requests[request{method, url}] = true
+		if t.paths[start-t.lastColumn].remainder {
+			// A remainder wildcard also matches more tokens.
+			s.requests[request{method, url + "/" + s.placeholder}] = true
+		}
+	}
+}
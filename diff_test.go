@@ -0,0 +1,162 @@
+package tinyrouter
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	show := func(w http.ResponseWriter, r *http.Request) {}
+	edit := func(w http.ResponseWriter, r *http.Request) {}
+	route := func(method, pattern string, handle http.HandlerFunc) Route {
+		return Route{Method: method, Pattern: pattern, HandleFunc: handle}
+	}
+	named := func(r Route, name string) Route {
+		r.Name = name
+		return r
+	}
+
+	tests := []struct {
+		old, new Config
+		want     string
+	}{{
+		old: Config{Routes: []Route{
+			route("GET", "/users/:name", show),
+			route("GET", "/posts/:id", show),
+		}},
+		new: Config{Routes: []Route{
+			route("GET", "/posts/:id", show),
+			route("GET", "/users/:name", show),
+		}},
+		want: "",
+	}, {
+		old: Config{Routes: []Route{
+			route("GET", "/users/:name", show),
+			named(route("GET", "/posts/:id", show), "post"),
+			route("POST", "/posts/:id", edit),
+			route("", "/old", show),
+		}},
+		new: Config{Routes: []Route{
+			route("GET", "/users/:name", edit),
+			route("GET", "/users/new", edit),
+			named(route("GET", "/articles/:id", show), "post"),
+			route("POST", "/posts/:id", edit),
+			{Method: "POST", Pattern: "/posts/:id/likes", HandleFunc: edit, Metadata: map[string]string{"a": "b"}},
+		}},
+		want: `added    GET /users/new
+added    POST /posts/:id/likes
+removed  * /old
+modified GET /posts/:id [post] => GET /articles/:id [post]: Pattern
+modified GET /users/:name: HandleFunc
+rerouted GET /users/new: GET /users/:name => GET /users/new
+rerouted POST /posts/x/likes: POST /posts/:id => POST /posts/:id/likes
+`,
+	}, {
+		// The added route shadows the route matching any method.
+		old: Config{ServeMuxSyntax: true, Routes: []Route{
+			route("", "/files/{name}", show),
+		}},
+		new: Config{ServeMuxSyntax: true, Routes: []Route{
+			route("", "/files/{name}", show),
+			route("GET", "/files/{name}", edit),
+		}},
+		want: `added    GET /files/{name}
+rerouted GET /files/x: * /files/{name} => GET /files/{name}
+`,
+	}, {
+		// The HEAD requests are reported if they are routed differently
+		// from the GET ones.
+		old: Config{ServeMuxSyntax: true, Routes: []Route{
+			route("GET", "/{a}", show),
+		}},
+		new: Config{ServeMuxSyntax: true, Routes: []Route{
+			route("GET", "/{a}", show),
+			route("HEAD", "/x", edit),
+		}},
+		want: `added    HEAD /x
+rerouted HEAD /x: GET /{a} => HEAD /x
+`,
+	}, {
+		// The request paths not matched without backtracking.
+		old: Config{Routes: []Route{
+			route("GET", "/a/:x/c", show),
+			route("GET", "/a/b/d", show),
+		}},
+		new: Config{NoBacktracking: true, Routes: []Route{
+			route("GET", "/a/:x/c", show),
+			route("GET", "/a/b/d", show),
+		}},
+		want: `rerouted GET /a/b/c: GET /a/:x/c => no routes
+`,
+	}, {
+		// The routes are identified by the resolved methods.
+		old: Config{ServeMuxSyntax: true, Routes: []Route{
+			route("", "GET /{a}/{rest...}", show),
+			route("", "GET /{a}/x", show),
+		}},
+		new: Config{ServeMuxSyntax: true, Routes: []Route{
+			route("GET", "GET /{a}/{rest...}", show),
+			route("", "GET /x/{b}", show),
+		}},
+		want: `added    GET /x/{b}
+removed  GET /{a}/x
+rerouted GET /x/x1: GET /{a}/{rest...} => GET /x/{b}
+rerouted GET /x1/x: GET /{a}/x => GET /{a}/{rest...}
+`,
+	}}
+	for i, test := range tests {
+		d := Diff(New(test.old), New(test.new))
+		if got := d.String(); got != test.want {
+			t.Errorf("#%d: Diff returns\n%s\nwant\n%s", i, got, test.want)
+		}
+		if d.Empty() != (test.want == "") {
+			t.Errorf("#%d: Empty returns %v", i, d.Empty())
+		}
+	}
+}
+
+func TestDiffRouteFiles(t *testing.T) {
+	handle := func(w http.ResponseWriter, r *http.Request) {}
+	registry := map[string]http.HandlerFunc{"a": handle, "b": handle}
+	load := func(content string, routes ...Route) *TinyRouter {
+		t.Helper()
+		tr, err := LoadRouter(strings.NewReader(content), RouteFileJSON, registry, func(c *Config) {
+			c.Routes = append(c.Routes, routes...)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tr
+	}
+
+	old := `{"routes": [{"method": "GET", "pattern": "/x", "handler": "a"}, {"method": "GET", "pattern": "/y", "handler": "a"}]}`
+	new := `{"routes": [{"method": "GET", "pattern": "/x", "handler": "b"}, {"method": "GET", "pattern": "/y", "handler": "a"}]}`
+
+	// The handlers are compared by their names.
+	d := Diff(load(old), load(new))
+	if want := "modified GET /x: HandleFunc\n"; d.String() != want {
+		t.Errorf("Diff returns\n%s\nwant\n%s", d.String(), want)
+	}
+
+	// The handlers of the routes added by configure are compared by their code.
+	other := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTeapot) }
+	z := `{"routes": [{"method": "GET", "pattern": "/x", "handler": "a"}, {"method": "GET", "pattern": "/z", "handler": "a"}]}`
+	d = Diff(load(old, Route{Method: "GET", Pattern: "/z", HandleFunc: handle}), load(z, Route{Method: "GET", Pattern: "/y", HandleFunc: other}))
+	if want := "modified GET /y: HandleFunc\n"; d.String() != want {
+		t.Errorf("Diff returns\n%s\nwant\n%s", d.String(), want)
+	}
+
+	// The routers built by New only know the code of the handlers.
+	var routers []*TinyRouter
+	for _, content := range []string{old, new} {
+		c, err := LoadConfig(strings.NewReader(content), RouteFileJSON, registry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		routers = append(routers, New(c))
+	}
+	if d := Diff(routers[0], routers[1]); !d.Empty() {
+		t.Errorf("Diff returns\n%s\nwant no differences", d)
+	}
+}
@@ -0,0 +1,102 @@
+package tinyrouter
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DumpDot returns the segment graph of the router in the DOT language of
+// Graphviz, for visualizing routing tables. Each path group (the routes
+// with the same method and number of segments) is a tree, the nodes of
+// which are the distinct fixed tokens and the parameters in the columns,
+// and the leaves of which are the patterns. The edges to the parameters
+// are dashed, as they are only followed when no fixed tokens match.
+func (tr *TinyRouter) DumpDot() string {
+	d := &dotWriter{}
+	d.printf("digraph routes {\n")
+	d.printf("\trankdir=LR;\n")
+	d.printf("\tnode [shape=box];\n")
+
+	for _, method := range tr.methods() {
+		name := method
+		if name == "" {
+			name = "*"
+		}
+		for _, kind := range []string{"", " ending with remainder wildcards"} {
+			tables := tr.tables.get(method)
+			if kind != "" {
+				tables = tr.remainderTables.get(method)
+			}
+			if tables == nil {
+				continue
+			}
+			for numTokens, t := range tables {
+				if t == nil {
+					continue
+				}
+				root := d.node(fmt.Sprintf("%s routes with %d segments%s", name, numTokens+1, kind), "shape=plaintext")
+				d.group(t, 0, t.numRows, root)
+			}
+		}
+	}
+
+	d.printf("}\n")
+	return d.b.String()
+}
+
+type dotWriter struct {
+	b        strings.Builder
+	numNodes int
+}
+
+func (d *dotWriter) printf(format string, args ...any) {
+	fmt.Fprintf(&d.b, format, args...)
+}
+
+// node writes a node with the label and attributes, and returns its ID.
+func (d *dotWriter) node(label, attrs string) string {
+	id := "n" + strconv.Itoa(d.numNodes)
+	d.numNodes++
+	if attrs != "" {
+		attrs = ", " + attrs
+	}
+	d.printf("\t%s [label=%s%s];\n", id, strconv.Quote(label), attrs)
+	return id
+}
+
+// group writes the nodes of the segment group from index start to index
+// end (exclusive) in t and the groups following it, which are children
+// of the node parent.
+func (d *dotWriter) group(t *segmentTable, start, end int32, parent string) {
+	t.eachGroup(start, end, func(first, next int32, wildcard bool) {
+		if !wildcard {
+			id := d.node(t.segments[first].token, "")
+			d.printf("\t%s -> %s;\n", parent, id)
+			d.next(t, first, next, id)
+			return
+		}
+
+		var names []string
+		for k := first; k != next; k++ {
+			if name := ":" + t.segment(k).token; !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+		id := d.node(strings.Join(names, " | "), "style=rounded")
+		d.printf("\t%s -> %s [style=dashed];\n", parent, id)
+		d.next(t, first, next, id)
+	})
+}
+
+// next writes the nodes following the node of
+// the segments from index start to index end.
+func (d *dotWriter) next(t *segmentTable, start, end int32, parent string) {
+	if start >= t.lastColumn {
+		id := d.node(t.paths[start-t.lastColumn].raw, "shape=ellipse")
+		d.printf("\t%s -> %s;\n", parent, id)
+		return
+	}
+	d.group(t, start+t.numRows, end+t.numRows, parent)
+}
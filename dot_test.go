@@ -0,0 +1,39 @@
+package tinyrouter
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDumpDot(t *testing.T) {
+	h := func(http.ResponseWriter, *http.Request) {}
+	router := New(Config{Routes: []Route{
+		{Method: "GET", Pattern: "/users/:name", HandleFunc: h},
+		{Method: "GET", Pattern: "/users/new", HandleFunc: h},
+		{Pattern: "/:page", HandleFunc: h},
+	}})
+	want := `digraph routes {
+	rankdir=LR;
+	node [shape=box];
+	n0 [label="* routes with 1 segments", shape=plaintext];
+	n1 [label=":page", style=rounded];
+	n0 -> n1 [style=dashed];
+	n2 [label="/:page", shape=ellipse];
+	n1 -> n2;
+	n3 [label="GET routes with 2 segments", shape=plaintext];
+	n4 [label="users"];
+	n3 -> n4;
+	n5 [label="new"];
+	n4 -> n5;
+	n6 [label="/users/new", shape=ellipse];
+	n5 -> n6;
+	n7 [label=":name", style=rounded];
+	n4 -> n7 [style=dashed];
+	n8 [label="/users/:name", shape=ellipse];
+	n7 -> n8;
+}
+`
+	if got := router.DumpDot(); got != want {
+		t.Errorf("DumpDot:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,168 @@
+package tinyrouter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Explain returns a trace of looking up the route for a request with
+// method and urlPath, one step per line, for debugging routing tables.
+// The segment tables are walked the same way as Lookup does, except that
+// Config.MaxLookupSteps and the lookup cache are ignored. The last line
+// tells the selected route, if there is one.
+func (tr *TinyRouter) Explain(method, urlPath string) []string {
+	e := &explainer{tr: tr}
+	if !strings.HasPrefix(urlPath, "/") {
+		e.printf(0, "%q doesn't start with a slash", urlPath)
+		return e.lines
+	}
+	urlPath = urlPath[1:]
+	if len(urlPath) > 1024 {
+		e.printf(0, "the path is truncated to 1024 bytes")
+		urlPath = urlPath[:1024]
+	}
+
+	path := e.findPath(method, urlPath)
+	for _, fallback := range e.tr.fallbackMethods(method) {
+		if path != nil {
+			break
+		}
+		path = e.findPath(fallback, urlPath)
+	}
+	if path == nil {
+		e.printf(0, "no routes match")
+	} else {
+		e.printf(0, "selected %s", path.raw)
+	}
+	return e.lines
+}
+
+type explainer struct {
+	tr    *TinyRouter
+	lines []string
+}
+
+func (e *explainer) printf(depth int, format string, args ...any) {
+	e.lines = append(e.lines, strings.Repeat("  ", depth)+fmt.Sprintf(format, args...))
+}
+
+// findPath is like TinyRouter.findPath.
+func (e *explainer) findPath(method, urlPath string) *path {
+	tr := e.tr
+	name := method
+	if name == "" {
+		name = "*"
+	}
+	var tokens pathTokens
+	tokens.tokenize(urlPath, tr.numTokens())
+
+	tables, remainderTables := tr.tables.get(method), tr.remainderTables.get(method)
+	if tables == nil && remainderTables == nil {
+		e.printf(0, "no %s routes", name)
+		return nil
+	}
+
+	if tables != nil {
+		switch {
+		case tokens.n > tr.maxNumTokens:
+			e.printf(0, "no %s routes with more than %d segments", name, tr.maxNumTokens)
+		case tables[tokens.n-1] == nil:
+			e.printf(0, "no %s routes with %d segments", name, tokens.n)
+		default:
+			t := tables[tokens.n-1]
+			e.printf(0, "trying %s routes with %d segments", name, tokens.n)
+			if path := e.match(&tokens, t, 0, t.numRows, 0); path != nil {
+				return path
+			}
+		}
+	}
+
+	if remainderTables != nil {
+		for n := min(tokens.n, tr.maxNumTokens); n > 0; n-- {
+			if t := remainderTables[n-1]; t != nil {
+				tokens.n = n
+				e.printf(0, "trying %s routes with %d segments ending with remainder wildcards", name, n)
+				if path := e.match(&tokens, t, 0, t.numRows, 0); path != nil {
+					return path
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// match is like findHandlePath (or findBestPath if the precedence is not
+// PrecedenceLeftToRight), but it walks the segment group from index start
+// to index end (exclusive) in t by scanning it, and traces the steps.
+func (e *explainer) match(tokens *pathTokens, t *segmentTable, start, end int32, col int) *path {
+	tr, segs := e.tr, t.segments
+	leftToRight := tr.precedence == PrecedenceLeftToRight
+	token := tokens.token(col)
+
+	fixed, fixedEnd, wildcard := int32(noSegment), int32(noSegment), end
+	t.eachGroup(start, end, func(first, next int32, isWildcard bool) {
+		if isWildcard {
+			wildcard = first
+		} else if segs[first].token == token {
+			fixed, fixedEnd = first, next
+		}
+	})
+
+	var best *path
+	better := func(path *path) {
+		if path != nil && (best == nil || tr.precedence.precedes(path, best)) {
+			best = path
+		}
+	}
+
+	switch {
+	case fixed != noSegment:
+		e.printf(col+1, "%q matches %q", token, segs[fixed].token)
+		path := e.next(tokens, t, fixed, fixedEnd, col)
+		if leftToRight {
+			if path != nil || wildcard == end {
+				return path
+			}
+			if tr.noBacktracking {
+				e.printf(col+1, "no backtracking to the parameters after %q", segs[fixed].token)
+				return nil
+			}
+			e.printf(col+1, "backtracking to the parameters after %q", segs[fixed].token)
+		}
+		better(path)
+	case wildcard == end:
+		e.printf(col+1, "%q matches no segments", token)
+		return nil
+	case wildcard != start:
+		e.printf(col+1, "%q matches no fixed segments", token)
+	}
+	if wildcard == end {
+		return best
+	}
+
+	var names []string
+	for i := wildcard; i != end; i++ {
+		if name := ":" + t.segment(i).token; !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	e.printf(col+1, "%q matches %s", token, strings.Join(names, " or "))
+	path := e.next(tokens, t, wildcard, end, col)
+	if leftToRight {
+		return path
+	}
+	better(path)
+	return best
+}
+
+// next traces the segments following the ones from index start
+// to index end (exclusive) in t, which match the token at col.
+func (e *explainer) next(tokens *pathTokens, t *segmentTable, start, end int32, col int) *path {
+	if start >= t.lastColumn {
+		path := t.paths[start-t.lastColumn]
+		e.printf(col+1, "matched %s", path.raw)
+		return path
+	}
+	return e.match(tokens, t, start+t.numRows, end+t.numRows, col+1)
+}
@@ -0,0 +1,110 @@
+package tinyrouter
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	h := func(http.ResponseWriter, *http.Request) {}
+	router := New(Config{Routes: []Route{
+		{Method: "GET", Pattern: "/en/docs/intro", HandleFunc: h},
+		{Method: "GET", Pattern: "/en/{section}/{page}", HandleFunc: h},
+		{Pattern: "/files/{path...}", HandleFunc: h},
+	}, ServeMuxSyntax: true})
+
+	cases := []struct {
+		method, url string
+		want        []string
+	}{
+		{"GET", "/en/docs/x", []string{
+			`trying GET routes with 3 segments`,
+			`  "en" matches "en"`,
+			`    "docs" matches "docs"`,
+			`      "x" matches no segments`,
+			`    backtracking to the parameters after "docs"`,
+			`    "docs" matches :section`,
+			`      "x" matches :page`,
+			`      matched /en/{section}/{page}`,
+			`selected /en/{section}/{page}`,
+		}},
+		{"POST", "/files/a/b", []string{
+			`no POST routes`,
+			`trying * routes with 2 segments ending with remainder wildcards`,
+			`  "files" matches "files"`,
+			`    "a/b" matches :path`,
+			`    matched /files/{path...}`,
+			`selected /files/{path...}`,
+		}},
+		{"GET", "/a/b/c/d", []string{
+			`no GET routes with more than 3 segments`,
+			`trying * routes with 2 segments ending with remainder wildcards`,
+			`  "a" matches no segments`,
+			`no routes match`,
+		}},
+		{"GET", "a", []string{`"a" doesn't start with a slash`}},
+	}
+	for _, c := range cases {
+		if got := router.Explain(c.method, c.url); strings.Join(got, "\n") != strings.Join(c.want, "\n") {
+			t.Errorf("Explain(%s, %s):\n%s\nwant:\n%s", c.method, c.url, strings.Join(got, "\n"), strings.Join(c.want, "\n"))
+		}
+	}
+
+	// The parameters in the same segment group are listed together.
+	router = New(Config{Routes: []Route{
+		{Method: "GET", Pattern: "/:lang/docs/:page", HandleFunc: h},
+		{Method: "GET", Pattern: "/en/:section/intro", HandleFunc: h},
+		{Method: "GET", Pattern: "/:a/:b/:c", HandleFunc: h},
+	}})
+	want := []string{
+		`trying GET routes with 3 segments`,
+		`  "en" matches "en"`,
+		`    "docs" matches :section`,
+		`      "x" matches no segments`,
+		`  backtracking to the parameters after "en"`,
+		`  "en" matches :lang or :a`,
+		`    "docs" matches "docs"`,
+		`      "x" matches :page`,
+		`      matched /:lang/docs/:page`,
+		`selected /:lang/docs/:page`,
+	}
+	if got := router.Explain("GET", "/en/docs/x"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Explain(GET, /en/docs/x):\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// The traces must end with the routes selected by Lookup.
+func TestExplainRandomTables(t *testing.T) {
+	rd := rand.New(rand.NewPCG(3, 4))
+	for range 300 {
+		sm := rd.IntN(2) == 0
+		data := make([]byte, 200)
+		for i := range data {
+			data[i] = byte(rd.IntN(16))
+		}
+		router, patterns := fuzzRouter(data, sm, Precedence(rd.IntN(3)))
+		if router.precedence == PrecedenceLeftToRight {
+			router.noBacktracking = rd.IntN(2) == 0
+		}
+		for range 30 {
+			urlData := make([]byte, 1+rd.IntN(6))
+			for i := range urlData {
+				urlData[i] = byte(rd.IntN(12))
+			}
+			url := fuzzURLs(urlData)[0]
+			for _, method := range []string{"GET", "POST"} {
+				want := "no routes match"
+				if p, ok := router.Lookup(method, url); ok {
+					want = "selected " + p.Pattern()
+				}
+				lines := router.Explain(method, url)
+				if got := lines[len(lines)-1]; got != want {
+					t.Fatalf("%s %s (%s, NoBacktracking: %v): the trace ends with %q, want %q\n%s\nroutes:\n %s",
+						method, url, router.precedence, router.noBacktracking, got, want, strings.Join(lines, "\n"), strings.Join(patterns, "\n "))
+				}
+			}
+		}
+	}
+}
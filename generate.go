@@ -0,0 +1,329 @@
+package tinyrouter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A PathTemplate makes the Params values for the request paths matching
+// one of the paths a route pattern expands to. It is used by the routers
+// generated by Generate, which know the matched paths at compile time.
+type PathTemplate struct {
+	path *path
+}
+
+// NewPathTemplate returns the PathTemplate of the path with numSegments
+// segments which pattern expands to. It panics if there is no such path.
+func NewPathTemplate(pattern string, numSegments int) PathTemplate {
+	return newPathTemplate(pattern, false, numSegments)
+}
+
+// NewServeMuxPathTemplate is the same as NewPathTemplate, except that
+// pattern is in the syntax of http.ServeMux.
+func NewServeMuxPathTemplate(pattern string, numSegments int) PathTemplate {
+	return newPathTemplate(pattern, true, numSegments)
+}
+
+func newPathTemplate(pattern string, serveMux bool, numSegments int) PathTemplate {
+	paths, err := parsePattern(&Route{Pattern: pattern}, serveMux)
+	if err != nil {
+		panic(err)
+	}
+	for _, path := range paths {
+		if len(path.segments) == numSegments {
+			return PathTemplate{path}
+		}
+	}
+	panic(fmt.Sprintf("pattern %s has no paths with %d segments", pattern, numSegments))
+}
+
+// WithRoute returns a copy of t with the name and metadata of the route,
+// which are reported by Params.RouteName and Params.RouteMetadata.
+func (t PathTemplate) WithRoute(name string, metadata map[string]string) PathTemplate {
+	path := *t.path
+	path.name, path.metadata = name, metadata
+	return PathTemplate{&path}
+}
+
+// Params returns the Params value for urlPath (without the leading
+// slash), which must match the path of t.
+func (t PathTemplate) Params(urlPath string) Params {
+	return paramsOf(t.path, urlPath)
+}
+
+// Generate writes the Go source code of a router serving the routes
+// declared in f, in package pkg. The router type is named typeName,
+// and is created by a function named "New" + typeName, which takes
+// the handler functions by the handler names in f.
+//
+// The generated router looks up routes by nested switch statements on the
+// tokens of request paths, which are compiled from the segment tables of
+// a TinyRouter with the same routes. It selects the same routes and passes
+// the same Params values as the TinyRouter with PrecedenceLeftToRight (and
+// backtracking), so Params.Pattern and PathParams work as usual.
+//
+// Generate is used by the tinyrouter-gen command, which is designed to
+// be run by go generate.
+func Generate(w io.Writer, f RouteFile, pkg, typeName string) error {
+	if !token.IsIdentifier(pkg) {
+		return errors.New("tinyrouter: bad package name: " + pkg)
+	}
+	if !token.IsIdentifier(typeName) {
+		return errors.New("tinyrouter: bad type name: " + typeName)
+	}
+	if len(f.Routes) == 0 {
+		return errors.New("tinyrouter: no routes are declared")
+	}
+	for i, r := range f.Routes {
+		if r.Handler == "" {
+			return fmt.Errorf("tinyrouter: no handler for route %d (%s)", i, r.Pattern)
+		}
+	}
+	tr, err := f.newRouter()
+	if err != nil {
+		return err
+	}
+
+	g := &generator{tr: tr, serveMux: f.ServeMuxSyntax, index: make(map[*path]int)}
+	g.collectPaths()
+	g.printHeader(f, pkg, typeName)
+	g.printLookup(typeName)
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("tinyrouter: formatting generated code: %w", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// newRouter returns a TinyRouter with the routes in f, the handlers
+// of which are placeholders.
+func (f RouteFile) newRouter() (*TinyRouter, error) {
+	routes := make([]Route, len(f.Routes))
+	for i, d := range f.Routes {
+		routes[i] = d.route(http.NotFound)
+	}
+	tr, err := compile(Config{Routes: routes, ServeMuxSyntax: f.ServeMuxSyntax})
+	if err != nil {
+		return nil, fmt.Errorf("tinyrouter: %w", err)
+	}
+	return tr, nil
+}
+
+type generator struct {
+	tr       *TinyRouter
+	serveMux bool
+	buf      bytes.Buffer
+
+	methods []string      // sorted
+	paths   []*path       // in the generated path array
+	index   map[*path]int // index in paths
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// collectPaths orders the methods and the paths of the router.
+func (g *generator) collectPaths() {
+	g.methods = g.tr.methods()
+
+	for _, method := range g.methods {
+		for _, tables := range []*[maxSegmentsInPath]*segmentTable{g.tr.tables.get(method), g.tr.remainderTables.get(method)} {
+			if tables == nil {
+				continue
+			}
+			for _, t := range tables {
+				if t == nil {
+					continue
+				}
+				for _, path := range t.paths {
+					g.index[path] = len(g.paths)
+					g.paths = append(g.paths, path)
+				}
+			}
+		}
+	}
+}
+
+func (g *generator) printHeader(f RouteFile, pkg, typeName string) {
+	r, size := utf8.DecodeRuneInString(typeName)
+	pathsVar := string(unicode.ToLower(r)) + typeName[size:] + "Paths"
+
+	g.printf("// Code generated by tinyrouter-gen. DO NOT EDIT.\n\n")
+	g.printf("package %s\n\n", pkg)
+	g.printf("import (\n\"net/http\"\n\"strings\"\n\n\"go101.org/tinyrouter\"\n)\n\n")
+
+	g.printf("// %s dispatches requests to the handlers of the declared routes.\n", typeName)
+	g.printf("// It selects the same routes as a tinyrouter.TinyRouter with the routes.\n")
+	g.printf("type %s struct {\n", typeName)
+	g.printf("handlers [%d]http.HandlerFunc // by route\n", len(f.Routes))
+	g.printf("notFound http.HandlerFunc\n}\n\n")
+
+	g.printf("// New%s returns a %s calling the functions in handlers by the\n", typeName, typeName)
+	g.printf("// handler names of the routes. It panics if a handler is missing.\n")
+	g.printf("// Unmatched requests are handled by notFound, or http.NotFound if it is nil.\n")
+	g.printf("func New%s(handlers map[string]http.HandlerFunc, notFound http.HandlerFunc) *%s {\n", typeName, typeName)
+	g.printf("r := &%s{notFound: notFound}\n", typeName)
+	g.printf("if r.notFound == nil {\nr.notFound = http.NotFound\n}\n")
+	g.printf("for i, name := range [...]string{\n")
+	for _, r := range f.Routes {
+		g.printf("%s,\n", strconv.Quote(r.Handler))
+	}
+	g.printf("} {\n")
+	g.printf("if r.handlers[i] = handlers[name]; r.handlers[i] == nil {\n")
+	g.printf("panic(\"no handler named \" + name)\n}\n}\nreturn r\n}\n\n")
+
+	g.printf("// The paths which the route patterns expand to.\n")
+	g.printf("var %s = [...]struct {\nroute int\ntemplate tinyrouter.PathTemplate\n", pathsVar)
+	g.printf("context bool // whether or not to pass Params through the request context\n}{\n")
+	for _, path := range g.paths {
+		newTemplate := "NewPathTemplate"
+		if g.serveMux {
+			newTemplate = "NewServeMuxPathTemplate"
+		}
+		g.printf("{%d, tinyrouter.%s(%s, %d)", path.index, newTemplate, strconv.Quote(path.raw), len(path.segments))
+		if path.name != "" || len(path.metadata) > 0 {
+			g.printf(".WithRoute(%s, %s)", strconv.Quote(path.name), mapLiteral(path.metadata))
+		}
+		g.printf(", %t},\n", path.numParams > 0 || len(path.metadata) > 0)
+	}
+	g.printf("}\n\n")
+
+	g.printf("// ServeHTTP lets *%s implement http.Handler interface.\n", typeName)
+	g.printf("func (r *%s) ServeHTTP(w http.ResponseWriter, req *http.Request) {\n", typeName)
+	g.printf("i, urlPath := r.lookup(req.Method, req.URL.Path[1:])\n")
+	g.printf("if i < 0 {\nr.notFound(w, req)\nreturn\n}\n")
+	g.printf("p := &%s[i]\n", pathsVar)
+	g.printf("if p.context {\n")
+	g.printf("req = req.WithContext(tinyrouter.ContextWithParams(req.Context(), p.template.Params(urlPath)))\n}\n")
+	g.printf("r.handlers[p.route](w, req)\n}\n\n")
+
+	g.printf("// Lookup is like tinyrouter.TinyRouter.Lookup.\n")
+	g.printf("func (r *%s) Lookup(method, urlPath string) (tinyrouter.Params, bool) {\n", typeName)
+	g.printf("if !strings.HasPrefix(urlPath, \"/\") {\nreturn tinyrouter.Params{}, false\n}\n")
+	g.printf("i, urlPath := r.lookup(method, urlPath[1:])\n")
+	g.printf("if i < 0 {\nreturn tinyrouter.Params{}, false\n}\n")
+	g.printf("return %s[i].template.Params(urlPath), true\n}\n\n", pathsVar)
+
+	g.printf("// lookup returns the index of the path matching urlPath (without the\n")
+	g.printf("// leading slash) and the possibly truncated urlPath, or -1 if no paths match.\n")
+	g.printf("func (r *%s) lookup(method, urlPath string) (int, string) {\n", typeName)
+	g.printf("if len(urlPath) > 1024 {\nurlPath = urlPath[:1024]\n}\n")
+	g.printf("i := r.lookupMethod(method, urlPath)\n")
+	if g.serveMux {
+		// The fallback methods, see TinyRouter.fallbackMethods.
+		g.printf("if i < 0 && method == %q {\ni = r.lookupMethod(%q, urlPath)\n}\n", http.MethodHead, http.MethodGet)
+		g.printf("if i < 0 && method != \"\" {\ni = r.lookupMethod(\"\", urlPath)\n}\n")
+	}
+	g.printf("return i, urlPath\n}\n\n")
+
+	// The helpers are methods to not conflict with the ones
+	// of other routers generated in the same package.
+	g.printf("// split splits urlPath into tokens, the last one of which\n")
+	g.printf("// holds the remaining path. It returns the number of tokens.\n")
+	g.printf("func (*%s) split(tokens *[%d]string, urlPath string) int {\n", typeName, g.tr.numTokens())
+	g.printf("n := 0\nfor ; n < len(tokens)-1; n++ {\n")
+	g.printf("i := strings.IndexByte(urlPath, '/')\nif i < 0 {\nbreak\n}\n")
+	g.printf("tokens[n], urlPath = urlPath[:i], urlPath[i+1:]\n}\n")
+	g.printf("tokens[n] = urlPath\nreturn n + 1\n}\n\n")
+}
+
+// printLookup prints the lookupMethod method, which walks the segment
+// tables like findHandlePath, with fixed segments being tried before the
+// wildcard ones in the same column. A nested switch which matches no paths
+// falls through to the code for the wildcard segments following it.
+// The remainder wildcards are always in the last columns, so the tokens
+// they match, which would be the remaining paths, are never compared.
+func (g *generator) printLookup(typeName string) {
+	g.printf("// lookupMethod returns the index of the path matching urlPath\n")
+	g.printf("// among the ones of method, or -1 if there is none.\n")
+	g.printf("func (r *%s) lookupMethod(method, urlPath string) int {\n", typeName)
+	g.printf("var tokens [%d]string\n", g.tr.numTokens())
+	g.printf("n := r.split(&tokens, urlPath)\n")
+	g.printf("switch method {\n")
+	for _, method := range g.methods {
+		g.printf("case %s:\n", strconv.Quote(method))
+		if tables := g.tr.tables.get(method); tables != nil {
+			g.printf("switch n {\n")
+			for _, t := range tables {
+				if t != nil {
+					g.printf("case %d:\n", len(t.paths[0].segments))
+					g.printBlock(t, 0, t.numRows, 0)
+				}
+			}
+			g.printf("}\n")
+		}
+		if tables := g.tr.remainderTables.get(method); tables != nil {
+			for numTokens := len(tables); numTokens > 0; numTokens-- {
+				t := tables[numTokens-1]
+				if t == nil {
+					continue
+				}
+				g.printf("if n >= %d {\n", numTokens)
+				g.printBlock(t, 0, t.numRows, 0)
+				g.printf("}\n")
+			}
+		}
+	}
+	g.printf("}\nreturn -1\n}\n")
+}
+
+// printBlock prints the code matching tokens[col] with the segment group
+// from index start to index end (exclusive) in t.
+func (g *generator) printBlock(t *segmentTable, start, end int32, col int) {
+	if !t.wildcard(start) {
+		g.printf("switch tokens[%d] {\n", col)
+	}
+	t.eachGroup(start, end, func(first, next int32, wildcard bool) {
+		if wildcard {
+			g.printNext(t, first, next, col)
+			return
+		}
+		g.printf("case %s:\n", strconv.Quote(t.segments[first].token))
+		g.printNext(t, first, next, col)
+		if next == end || t.wildcard(next) {
+			g.printf("}\n")
+		}
+	})
+}
+
+// printNext prints the code following the match of tokens[col] with
+// the segments from index start to index end (exclusive) in t.
+func (g *generator) printNext(t *segmentTable, start, end int32, col int) {
+	if start >= t.lastColumn {
+		path := t.paths[start-t.lastColumn]
+		g.printf("return %d // %s\n", g.index[path], strings.ReplaceAll(path.raw, "\n", " "))
+		return
+	}
+	g.printBlock(t, start+t.numRows, end+t.numRows, col+1)
+}
+
+// mapLiteral returns the Go expression of m, with the keys being sorted.
+func mapLiteral(m map[string]string) string {
+	if m == nil {
+		return "nil"
+	}
+	var b strings.Builder
+	b.WriteString("map[string]string{")
+	for i, k := range slices.Sorted(maps.Keys(m)) {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(strconv.Quote(k) + ": " + strconv.Quote(m[k]))
+	}
+	b.WriteString("}")
+	return b.String()
+}
@@ -0,0 +1,34 @@
+package tinyrouter
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestGenerateErrors(t *testing.T) {
+	routes := []RouteDecl{{Method: "GET", Pattern: "/users/:name", Handler: "getUser"}}
+	cases := []struct {
+		file          RouteFile
+		pkg, typeName string
+		want          string
+	}{
+		{RouteFile{Routes: routes}, "main", "Router", ""},
+		{RouteFile{Routes: routes}, "my-pkg", "Router", "bad package name: my-pkg"},
+		{RouteFile{Routes: routes}, "main", "", "bad type name: "},
+		{RouteFile{}, "main", "Router", "no routes are declared"},
+		{RouteFile{Routes: []RouteDecl{{Pattern: "/"}}}, "main", "Router", "no handler for route 0 (/)"},
+		{RouteFile{Routes: []RouteDecl{{Pattern: "users", Handler: "h"}}}, "main", "Router", "a pattern shell start with a slash: users"},
+		{RouteFile{Routes: append(routes, RouteDecl{Method: "GET", Pattern: "/users/:id", Handler: "h"})}, "main", "Router", "Equal paths are not allowed"},
+		{RouteFile{Routes: []RouteDecl{{Pattern: "GET /items/{id}", Handler: "h"}}, ServeMuxSyntax: true}, "main", "Router", ""},
+	}
+	for _, c := range cases {
+		err := Generate(io.Discard, c.file, c.pkg, c.typeName)
+		switch {
+		case c.want == "" && err != nil:
+			t.Errorf("%v: %v", c.file, err)
+		case c.want != "" && (err == nil || !strings.Contains(err.Error(), c.want)):
+			t.Errorf("%v: got error %v, want %q", c.file, err, c.want)
+		}
+	}
+}
@@ -1 +1,8 @@
 module go101.org/tinyrouter
+
+go 1.23
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	gopkg.in/yaml.v3 v3.0.1
+)
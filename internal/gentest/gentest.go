@@ -0,0 +1,6 @@
+// Package gentest holds the routers generated by tinyrouter-gen from the
+// route files in this directory, to test them against TinyRouter.
+package gentest
+
+//go:generate go run go101.org/tinyrouter/cmd/tinyrouter-gen -o router_gen.go routes.json
+//go:generate go run go101.org/tinyrouter/cmd/tinyrouter-gen -type ServeMuxRouter -o servemux_router_gen.go servemux_routes.json
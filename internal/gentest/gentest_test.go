@@ -0,0 +1,137 @@
+package gentest
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"go101.org/tinyrouter"
+)
+
+type generatedRouter interface {
+	http.Handler
+	Lookup(method, urlPath string) (tinyrouter.Params, bool)
+}
+
+var generated = []struct {
+	routeFile, typeName, output string
+	newRouter                   func(map[string]http.HandlerFunc, http.HandlerFunc) generatedRouter
+}{
+	{"routes.json", "Router", "router_gen.go", func(h map[string]http.HandlerFunc, notFound http.HandlerFunc) generatedRouter {
+		return NewRouter(h, notFound)
+	}},
+	{"servemux_routes.json", "ServeMuxRouter", "servemux_router_gen.go", func(h map[string]http.HandlerFunc, notFound http.HandlerFunc) generatedRouter {
+		return NewServeMuxRouter(h, notFound)
+	}},
+}
+
+func readRouteFile(t *testing.T, name string) tinyrouter.RouteFile {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	routes, err := tinyrouter.ReadRouteFile(f, tinyrouter.RouteFileJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return routes
+}
+
+func TestGeneratedFilesUpToDate(t *testing.T) {
+	for _, g := range generated {
+		var b bytes.Buffer
+		if err := tinyrouter.Generate(&b, readRouteFile(t, g.routeFile), "gentest", g.typeName); err != nil {
+			t.Fatal(err)
+		}
+		old, err := os.ReadFile(g.output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b.Bytes(), old) {
+			t.Errorf("%s is out of date, run go generate", g.output)
+		}
+	}
+}
+
+// describe describes the handler name and the parameters of a request.
+func describe(name string, p tinyrouter.Params) string {
+	_, values := p.ToMapAndSlice()
+	return fmt.Sprintf("%s %s %q %q %s %q", name, p.Pattern(), p.Names(), values, p.RouteName(), p.RouteMetadata("to"))
+}
+
+// The generated routers must select the same routes and pass the same
+// parameters as TinyRouter.
+func TestGeneratedRouters(t *testing.T) {
+	for _, g := range generated {
+		f := readRouteFile(t, g.routeFile)
+		handlers := make(map[string]http.HandlerFunc)
+		var tokens []string
+		for _, r := range f.Routes {
+			handlers[r.Handler] = func(w http.ResponseWriter, req *http.Request) {
+				fmt.Fprint(w, describe(r.Handler, tinyrouter.PathParams(req)))
+			}
+			pattern := r.Pattern[strings.IndexByte(r.Pattern, '/')+1:]
+			for _, token := range strings.Split(pattern, "/") {
+				if !strings.HasPrefix(token, ":") && !strings.Contains(token, "{") {
+					tokens = append(tokens, strings.TrimPrefix(token, `\`))
+				}
+			}
+		}
+		tokens = append(tokens, "", "x", "yz")
+		notFound := func(w http.ResponseWriter, req *http.Request) {
+			fmt.Fprint(w, "not found")
+		}
+		file, err := os.Open(g.routeFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := tinyrouter.LoadConfig(file, tinyrouter.RouteFileJSON, handlers)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.OthersHandleFunc = notFound
+		router := tinyrouter.New(c)
+		genRouter := g.newRouter(handlers, notFound)
+
+		urls := []string{"/", "//", "/files/" + strings.Repeat("a/", 600)}
+		rnd := rand.New(rand.NewPCG(1, 2))
+		for range 20000 {
+			path := make([]string, 1+rnd.IntN(6))
+			for i := range path {
+				path[i] = tokens[rnd.IntN(len(tokens))]
+			}
+			urls = append(urls, "/"+strings.Join(path, "/"))
+		}
+
+		for _, url := range urls {
+			for _, method := range []string{"GET", "HEAD", "POST", "DELETE", "PROPFIND", "PUT"} {
+				want, wantOk := router.Lookup(method, url)
+				got, gotOk := genRouter.Lookup(method, url)
+				_, wantValues := want.ToMapAndSlice()
+				_, gotValues := got.ToMapAndSlice()
+				if gotOk != wantOk || got.Pattern() != want.Pattern() || !slices.Equal(gotValues, wantValues) || got.RouteName() != want.RouteName() {
+					t.Fatalf("%s: %s %s: generated: %v %s %q, TinyRouter: %v %s %q",
+						g.typeName, method, url, gotOk, got.Pattern(), gotValues, wantOk, want.Pattern(), wantValues)
+				}
+
+				req := httptest.NewRequest(method, url, nil)
+				w1, w2 := httptest.NewRecorder(), httptest.NewRecorder()
+				router.ServeHTTP(w1, req)
+				genRouter.ServeHTTP(w2, req)
+				if w1.Body.String() != w2.Body.String() {
+					t.Fatalf("%s: %s %s: generated router serves %q, TinyRouter serves %q",
+						g.typeName, method, url, w2.Body.String(), w1.Body.String())
+				}
+			}
+		}
+	}
+}
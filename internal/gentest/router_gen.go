@@ -0,0 +1,333 @@
+// Code generated by tinyrouter-gen. DO NOT EDIT.
+
+package gentest
+
+import (
+	"net/http"
+	"strings"
+
+	"go101.org/tinyrouter"
+)
+
+// Router dispatches requests to the handlers of the declared routes.
+// It selects the same routes as a tinyrouter.TinyRouter with the routes.
+type Router struct {
+	handlers [39]http.HandlerFunc // by route
+	notFound http.HandlerFunc
+}
+
+// NewRouter returns a Router calling the functions in handlers by the
+// handler names of the routes. It panics if a handler is missing.
+// Unmatched requests are handled by notFound, or http.NotFound if it is nil.
+func NewRouter(handlers map[string]http.HandlerFunc, notFound http.HandlerFunc) *Router {
+	r := &Router{notFound: notFound}
+	if r.notFound == nil {
+		r.notFound = http.NotFound
+	}
+	for i, name := range [...]string{
+		"home",
+		"getUser",
+		"newUser",
+		"createUser",
+		"anyUser",
+		"deleteUser",
+		"userPosts",
+		"latestPosts",
+		"docs",
+		"intro",
+		"sectionIntro",
+		"page",
+		"special",
+		"file",
+		"propfind",
+		"redirect",
+		"redirect",
+		"v1",
+		"v1",
+		"v1",
+		"v1",
+		"v1",
+		"v1",
+		"v1",
+		"v1",
+		"v1",
+		"v1",
+		"v1",
+		"v1",
+		"v1",
+		"v1",
+		"v1",
+		"v1",
+		"v1",
+		"v1",
+		"v1",
+		"v1",
+		"v1Any",
+		"v1Action",
+	} {
+		if r.handlers[i] = handlers[name]; r.handlers[i] == nil {
+			panic("no handler named " + name)
+		}
+	}
+	return r
+}
+
+// The paths which the route patterns expand to.
+var routerPaths = [...]struct {
+	route    int
+	template tinyrouter.PathTemplate
+	context  bool // whether or not to pass Params through the request context
+}{
+	{5, tinyrouter.NewPathTemplate("/users/:name", 2), true},
+	{0, tinyrouter.NewPathTemplate("/", 1), false},
+	{15, tinyrouter.NewPathTemplate("/old", 1).WithRoute("oldHome", map[string]string{"to": "/"}), true},
+	{12, tinyrouter.NewPathTemplate("/\\:special", 1), false},
+	{16, tinyrouter.NewPathTemplate("/old/:page", 2).WithRoute("", map[string]string{"code": "301", "to": "/docs"}), true},
+	{2, tinyrouter.NewPathTemplate("/users/new", 2), false},
+	{1, tinyrouter.NewPathTemplate("/users/:name", 2).WithRoute("user", nil), true},
+	{9, tinyrouter.NewPathTemplate("/en/docs/intro", 3), false},
+	{10, tinyrouter.NewPathTemplate("/en/:section/intro", 3), true},
+	{28, tinyrouter.NewPathTemplate("/v1/mu/:id", 3), true},
+	{29, tinyrouter.NewPathTemplate("/v1/nu/:id", 3), true},
+	{32, tinyrouter.NewPathTemplate("/v1/pi/:id", 3), true},
+	{30, tinyrouter.NewPathTemplate("/v1/xi/:id", 3), true},
+	{23, tinyrouter.NewPathTemplate("/v1/eta/:id", 3), true},
+	{33, tinyrouter.NewPathTemplate("/v1/rho/:id", 3), true},
+	{35, tinyrouter.NewPathTemplate("/v1/tau/:id", 3), true},
+	{18, tinyrouter.NewPathTemplate("/v1/beta/:id", 3), true},
+	{25, tinyrouter.NewPathTemplate("/v1/iota/:id", 3), true},
+	{22, tinyrouter.NewPathTemplate("/v1/zeta/:id", 3), true},
+	{17, tinyrouter.NewPathTemplate("/v1/alpha/:id", 3), true},
+	{20, tinyrouter.NewPathTemplate("/v1/delta/:id", 3), true},
+	{19, tinyrouter.NewPathTemplate("/v1/gamma/:id", 3), true},
+	{26, tinyrouter.NewPathTemplate("/v1/kappa/:id", 3), true},
+	{34, tinyrouter.NewPathTemplate("/v1/sigma/:id", 3), true},
+	{24, tinyrouter.NewPathTemplate("/v1/theta/:id", 3), true},
+	{27, tinyrouter.NewPathTemplate("/v1/lambda/:id", 3), true},
+	{21, tinyrouter.NewPathTemplate("/v1/epsilon/:id", 3), true},
+	{31, tinyrouter.NewPathTemplate("/v1/omicron/:id", 3), true},
+	{36, tinyrouter.NewPathTemplate("/v1/upsilon/:id", 3), true},
+	{37, tinyrouter.NewPathTemplate("/v1/:resource/:id", 3), true},
+	{6, tinyrouter.NewPathTemplate("/users/:name/posts/:page?=1", 3), true},
+	{8, tinyrouter.NewPathTemplate("/:lang/docs/:page", 3), true},
+	{11, tinyrouter.NewPathTemplate("/:lang/:section/:page/:anchor?", 3), true},
+	{38, tinyrouter.NewPathTemplate("/v1/alpha/:id/:action", 4), true},
+	{13, tinyrouter.NewPathTemplate("/files/:a/:b/:c", 4), true},
+	{7, tinyrouter.NewPathTemplate("/users/new/posts/latest", 4), false},
+	{6, tinyrouter.NewPathTemplate("/users/:name/posts/:page?=1", 4), true},
+	{11, tinyrouter.NewPathTemplate("/:lang/:section/:page/:anchor?", 4), true},
+	{3, tinyrouter.NewPathTemplate("/users", 1), false},
+	{14, tinyrouter.NewPathTemplate("/files/:a", 2), true},
+	{4, tinyrouter.NewPathTemplate("/users/:name", 2), true},
+}
+
+// ServeHTTP lets *Router implement http.Handler interface.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	i, urlPath := r.lookup(req.Method, req.URL.Path[1:])
+	if i < 0 {
+		r.notFound(w, req)
+		return
+	}
+	p := &routerPaths[i]
+	if p.context {
+		req = req.WithContext(tinyrouter.ContextWithParams(req.Context(), p.template.Params(urlPath)))
+	}
+	r.handlers[p.route](w, req)
+}
+
+// Lookup is like tinyrouter.TinyRouter.Lookup.
+func (r *Router) Lookup(method, urlPath string) (tinyrouter.Params, bool) {
+	if !strings.HasPrefix(urlPath, "/") {
+		return tinyrouter.Params{}, false
+	}
+	i, urlPath := r.lookup(method, urlPath[1:])
+	if i < 0 {
+		return tinyrouter.Params{}, false
+	}
+	return routerPaths[i].template.Params(urlPath), true
+}
+
+// lookup returns the index of the path matching urlPath (without the
+// leading slash) and the possibly truncated urlPath, or -1 if no paths match.
+func (r *Router) lookup(method, urlPath string) (int, string) {
+	if len(urlPath) > 1024 {
+		urlPath = urlPath[:1024]
+	}
+	i := r.lookupMethod(method, urlPath)
+	return i, urlPath
+}
+
+// split splits urlPath into tokens, the last one of which
+// holds the remaining path. It returns the number of tokens.
+func (*Router) split(tokens *[4]string, urlPath string) int {
+	n := 0
+	for ; n < len(tokens)-1; n++ {
+		i := strings.IndexByte(urlPath, '/')
+		if i < 0 {
+			break
+		}
+		tokens[n], urlPath = urlPath[:i], urlPath[i+1:]
+	}
+	tokens[n] = urlPath
+	return n + 1
+}
+
+// lookupMethod returns the index of the path matching urlPath
+// among the ones of method, or -1 if there is none.
+func (r *Router) lookupMethod(method, urlPath string) int {
+	var tokens [4]string
+	n := r.split(&tokens, urlPath)
+	switch method {
+	case "DELETE":
+		switch n {
+		case 2:
+			switch tokens[0] {
+			case "users":
+				return 0 // /users/:name
+			}
+		}
+	case "GET":
+		switch n {
+		case 1:
+			switch tokens[0] {
+			case "":
+				return 1 // /
+			case "old":
+				return 2 // /old
+			case ":special":
+				return 3 // /\:special
+			}
+		case 2:
+			switch tokens[0] {
+			case "old":
+				return 4 // /old/:page
+			case "users":
+				switch tokens[1] {
+				case "new":
+					return 5 // /users/new
+				}
+				return 6 // /users/:name
+			}
+		case 3:
+			switch tokens[0] {
+			case "en":
+				switch tokens[1] {
+				case "docs":
+					switch tokens[2] {
+					case "intro":
+						return 7 // /en/docs/intro
+					}
+				}
+				switch tokens[2] {
+				case "intro":
+					return 8 // /en/:section/intro
+				}
+			case "v1":
+				switch tokens[1] {
+				case "mu":
+					return 9 // /v1/mu/:id
+				case "nu":
+					return 10 // /v1/nu/:id
+				case "pi":
+					return 11 // /v1/pi/:id
+				case "xi":
+					return 12 // /v1/xi/:id
+				case "eta":
+					return 13 // /v1/eta/:id
+				case "rho":
+					return 14 // /v1/rho/:id
+				case "tau":
+					return 15 // /v1/tau/:id
+				case "beta":
+					return 16 // /v1/beta/:id
+				case "iota":
+					return 17 // /v1/iota/:id
+				case "zeta":
+					return 18 // /v1/zeta/:id
+				case "alpha":
+					return 19 // /v1/alpha/:id
+				case "delta":
+					return 20 // /v1/delta/:id
+				case "gamma":
+					return 21 // /v1/gamma/:id
+				case "kappa":
+					return 22 // /v1/kappa/:id
+				case "sigma":
+					return 23 // /v1/sigma/:id
+				case "theta":
+					return 24 // /v1/theta/:id
+				case "lambda":
+					return 25 // /v1/lambda/:id
+				case "epsilon":
+					return 26 // /v1/epsilon/:id
+				case "omicron":
+					return 27 // /v1/omicron/:id
+				case "upsilon":
+					return 28 // /v1/upsilon/:id
+				}
+				return 29 // /v1/:resource/:id
+			case "users":
+				switch tokens[2] {
+				case "posts":
+					return 30 // /users/:name/posts/:page?=1
+				}
+			}
+			switch tokens[1] {
+			case "docs":
+				return 31 // /:lang/docs/:page
+			}
+			return 32 // /:lang/:section/:page/:anchor?
+		case 4:
+			switch tokens[0] {
+			case "v1":
+				switch tokens[1] {
+				case "alpha":
+					return 33 // /v1/alpha/:id/:action
+				}
+			case "files":
+				return 34 // /files/:a/:b/:c
+			case "users":
+				switch tokens[1] {
+				case "new":
+					switch tokens[2] {
+					case "posts":
+						switch tokens[3] {
+						case "latest":
+							return 35 // /users/new/posts/latest
+						}
+					}
+				}
+				switch tokens[2] {
+				case "posts":
+					return 36 // /users/:name/posts/:page?=1
+				}
+			}
+			return 37 // /:lang/:section/:page/:anchor?
+		}
+	case "POST":
+		switch n {
+		case 1:
+			switch tokens[0] {
+			case "users":
+				return 38 // /users
+			}
+		}
+	case "PROPFIND":
+		switch n {
+		case 2:
+			switch tokens[0] {
+			case "files":
+				return 39 // /files/:a
+			}
+		}
+	case "PUT":
+		switch n {
+		case 2:
+			switch tokens[0] {
+			case "users":
+				return 40 // /users/:name
+			}
+		}
+	}
+	return -1
+}
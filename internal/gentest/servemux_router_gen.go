@@ -0,0 +1,208 @@
+// Code generated by tinyrouter-gen. DO NOT EDIT.
+
+package gentest
+
+import (
+	"net/http"
+	"strings"
+
+	"go101.org/tinyrouter"
+)
+
+// ServeMuxRouter dispatches requests to the handlers of the declared routes.
+// It selects the same routes as a tinyrouter.TinyRouter with the routes.
+type ServeMuxRouter struct {
+	handlers [11]http.HandlerFunc // by route
+	notFound http.HandlerFunc
+}
+
+// NewServeMuxRouter returns a ServeMuxRouter calling the functions in handlers by the
+// handler names of the routes. It panics if a handler is missing.
+// Unmatched requests are handled by notFound, or http.NotFound if it is nil.
+func NewServeMuxRouter(handlers map[string]http.HandlerFunc, notFound http.HandlerFunc) *ServeMuxRouter {
+	r := &ServeMuxRouter{notFound: notFound}
+	if r.notFound == nil {
+		r.notFound = http.NotFound
+	}
+	for i, name := range [...]string{
+		"home",
+		"files",
+		"index",
+		"static",
+		"css",
+		"createItem",
+		"editItem",
+		"deleteItems",
+		"abc",
+		"xyz",
+		"xb",
+	} {
+		if r.handlers[i] = handlers[name]; r.handlers[i] == nil {
+			panic("no handler named " + name)
+		}
+	}
+	return r
+}
+
+// The paths which the route patterns expand to.
+var serveMuxRouterPaths = [...]struct {
+	route    int
+	template tinyrouter.PathTemplate
+	context  bool // whether or not to pass Params through the request context
+}{
+	{3, tinyrouter.NewServeMuxPathTemplate("/static/", 2), false},
+	{9, tinyrouter.NewServeMuxPathTemplate("/x/{y}/{z...}", 3), true},
+	{7, tinyrouter.NewServeMuxPathTemplate("/items/", 2), false},
+	{0, tinyrouter.NewServeMuxPathTemplate("GET /{$}", 1), false},
+	{10, tinyrouter.NewServeMuxPathTemplate("GET /x/b/{$}", 3), false},
+	{2, tinyrouter.NewServeMuxPathTemplate("GET /files/{dir}/index.html", 3), true},
+	{6, tinyrouter.NewServeMuxPathTemplate("GET /items/{id}/:edit", 3), true},
+	{4, tinyrouter.NewServeMuxPathTemplate("GET /static/css/{file}", 3), true},
+	{1, tinyrouter.NewServeMuxPathTemplate("GET /files/{path...}", 2), true},
+	{8, tinyrouter.NewServeMuxPathTemplate("GET /a/b/{c...}", 3), true},
+	{5, tinyrouter.NewServeMuxPathTemplate("POST /items/{id}", 2), true},
+}
+
+// ServeHTTP lets *ServeMuxRouter implement http.Handler interface.
+func (r *ServeMuxRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	i, urlPath := r.lookup(req.Method, req.URL.Path[1:])
+	if i < 0 {
+		r.notFound(w, req)
+		return
+	}
+	p := &serveMuxRouterPaths[i]
+	if p.context {
+		req = req.WithContext(tinyrouter.ContextWithParams(req.Context(), p.template.Params(urlPath)))
+	}
+	r.handlers[p.route](w, req)
+}
+
+// Lookup is like tinyrouter.TinyRouter.Lookup.
+func (r *ServeMuxRouter) Lookup(method, urlPath string) (tinyrouter.Params, bool) {
+	if !strings.HasPrefix(urlPath, "/") {
+		return tinyrouter.Params{}, false
+	}
+	i, urlPath := r.lookup(method, urlPath[1:])
+	if i < 0 {
+		return tinyrouter.Params{}, false
+	}
+	return serveMuxRouterPaths[i].template.Params(urlPath), true
+}
+
+// lookup returns the index of the path matching urlPath (without the
+// leading slash) and the possibly truncated urlPath, or -1 if no paths match.
+func (r *ServeMuxRouter) lookup(method, urlPath string) (int, string) {
+	if len(urlPath) > 1024 {
+		urlPath = urlPath[:1024]
+	}
+	i := r.lookupMethod(method, urlPath)
+	if i < 0 && method == "HEAD" {
+		i = r.lookupMethod("GET", urlPath)
+	}
+	if i < 0 && method != "" {
+		i = r.lookupMethod("", urlPath)
+	}
+	return i, urlPath
+}
+
+// split splits urlPath into tokens, the last one of which
+// holds the remaining path. It returns the number of tokens.
+func (*ServeMuxRouter) split(tokens *[4]string, urlPath string) int {
+	n := 0
+	for ; n < len(tokens)-1; n++ {
+		i := strings.IndexByte(urlPath, '/')
+		if i < 0 {
+			break
+		}
+		tokens[n], urlPath = urlPath[:i], urlPath[i+1:]
+	}
+	tokens[n] = urlPath
+	return n + 1
+}
+
+// lookupMethod returns the index of the path matching urlPath
+// among the ones of method, or -1 if there is none.
+func (r *ServeMuxRouter) lookupMethod(method, urlPath string) int {
+	var tokens [4]string
+	n := r.split(&tokens, urlPath)
+	switch method {
+	case "":
+		if n >= 3 {
+			switch tokens[0] {
+			case "x":
+				return 1 // /x/{y}/{z...}
+			}
+		}
+		if n >= 2 {
+			switch tokens[0] {
+			case "static":
+				return 0 // /static/
+			}
+		}
+	case "DELETE":
+		if n >= 2 {
+			switch tokens[0] {
+			case "items":
+				return 2 // /items/
+			}
+		}
+	case "GET":
+		switch n {
+		case 1:
+			switch tokens[0] {
+			case "":
+				return 3 // GET /{$}
+			}
+		case 3:
+			switch tokens[0] {
+			case "x":
+				switch tokens[1] {
+				case "b":
+					switch tokens[2] {
+					case "":
+						return 4 // GET /x/b/{$}
+					}
+				}
+			case "files":
+				switch tokens[2] {
+				case "index.html":
+					return 5 // GET /files/{dir}/index.html
+				}
+			case "items":
+				switch tokens[2] {
+				case ":edit":
+					return 6 // GET /items/{id}/:edit
+				}
+			case "static":
+				switch tokens[1] {
+				case "css":
+					return 7 // GET /static/css/{file}
+				}
+			}
+		}
+		if n >= 3 {
+			switch tokens[0] {
+			case "a":
+				switch tokens[1] {
+				case "b":
+					return 9 // GET /a/b/{c...}
+				}
+			}
+		}
+		if n >= 2 {
+			switch tokens[0] {
+			case "files":
+				return 8 // GET /files/{path...}
+			}
+		}
+	case "POST":
+		switch n {
+		case 2:
+			switch tokens[0] {
+			case "items":
+				return 10 // POST /items/{id}
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,59 @@
+package tinyrouter
+
+import (
+	"encoding/json"
+	"log/slog"
+)
+
+// The value reported for sensitive parameters in logs and JSON.
+const redactedValue = "[REDACTED]"
+
+func (p Params) exportedValue(seg *segment) string {
+	if seg.sensitive {
+		return redactedValue
+	}
+	return p.value(seg)
+}
+
+// LogValue implements slog.LogValuer. The value is a group containing
+// the pattern of the matched route and a group of the parameters, in
+// the order they appear in the pattern. The values of the parameters
+// listed in Config.SensitiveParams are redacted.
+func (p Params) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, p.Len())
+	if p.path != nil {
+		for _, seg := range p.path.wildcards {
+			attrs = append(attrs, slog.String(seg.token, p.exportedValue(seg)))
+		}
+	}
+	return slog.GroupValue(
+		slog.String("pattern", p.Pattern()),
+		slog.Attr{Key: "params", Value: slog.GroupValue(attrs...)},
+	)
+}
+
+// MarshalJSON implements json.Marshaler. The result is an object like
+// {"pattern":"/users/:name","params":{"name":"alice"}}, in which the
+// parameters are in the order they appear in the pattern. The values
+// of the parameters listed in Config.SensitiveParams are redacted.
+func (p Params) MarshalJSON() ([]byte, error) {
+	b := append(make([]byte, 0, 64), `{"pattern":`...)
+	b = appendJSONString(b, p.Pattern())
+	b = append(b, `,"params":{`...)
+	if p.path != nil {
+		for i, seg := range p.path.wildcards {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendJSONString(b, seg.token)
+			b = append(b, ':')
+			b = appendJSONString(b, p.exportedValue(seg))
+		}
+	}
+	return append(b, "}}"...), nil
+}
+
+func appendJSONString(b []byte, s string) []byte {
+	data, _ := json.Marshal(s) // never fails
+	return append(b, data...)
+}
@@ -0,0 +1,56 @@
+package tinyrouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParamsLogValueAndJSON(t *testing.T) {
+	var params Params
+	router := New(Config{
+		SensitiveParams: []string{"token"},
+		Routes: []Route{{
+			Method:  "GET",
+			Pattern: "/users/:name/tokens/:token/:format?=json",
+			HandleFunc: func(w http.ResponseWriter, r *http.Request) {
+				params = PathParams(r)
+			},
+		}},
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", `/users/"bob"/tokens/s3cr3t`, nil))
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	const want = `{"pattern":"/users/:name/tokens/:token/:format?=json","params":{"name":"\"bob\"","token":"[REDACTED]","format":"json"}}`
+	if string(data) != want {
+		t.Errorf("json.Marshal:\n got %s\nwant %s", data, want)
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger.Info("request", "route", params)
+	const wantLog = `{"msg":"request","route":{"pattern":"/users/:name/tokens/:token/:format?=json","params":{"name":"\"bob\"","token":"[REDACTED]","format":"json"}}}` + "\n"
+	if buf.String() != wantLog {
+		t.Errorf("slog:\n got %s\nwant %s", buf.String(), wantLog)
+	}
+
+	if params.Value("token") != "s3cr3t" {
+		t.Errorf("Value should not be redacted")
+	}
+	if data, _ := json.Marshal(Params{}); string(data) != `{"pattern":"","params":{}}` {
+		t.Errorf("json.Marshal(Params{}) = %s", data)
+	}
+}
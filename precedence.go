@@ -0,0 +1,118 @@
+package tinyrouter
+
+// A Precedence specifies which route is selected
+// when more than one route matches a request path.
+//
+// Whatever the precedence is, routes with the request method are tried
+// before the ones with the fallback methods in the syntax of http.ServeMux
+// (see Route), and routes with remainder wildcards are only tried when no
+// other routes match (the ones with more segments first). The precedence
+// takes effect within each group.
+type Precedence int
+
+const (
+	// Scanning segments from left to right, the first route with a
+	// fixed segment where the others have wildcard segments wins.
+	// This is the default precedence.
+	PrecedenceLeftToRight Precedence = iota
+
+	// The route with the most fixed segments wins.
+	// Ties are broken by PrecedenceLeftToRight.
+	PrecedenceMostFixed
+
+	// The route declared first in Config.Routes wins.
+	PrecedenceDeclarationOrder
+)
+
+func (p Precedence) String() string {
+	switch p {
+	case PrecedenceLeftToRight:
+		return "left-to-right"
+	case PrecedenceMostFixed:
+		return "most-fixed"
+	case PrecedenceDeclarationOrder:
+		return "declaration-order"
+	}
+	return "unknown"
+}
+
+// precedes reports whether or not path x precedes path y. Both
+// of them are in the same group, so they have the same number
+// of segments.
+func (p Precedence) precedes(x, y *path) bool {
+	switch p {
+	case PrecedenceDeclarationOrder:
+		return x.index < y.index
+	case PrecedenceMostFixed:
+		if fx, fy := x.numFixed(), y.numFixed(); fx != fy {
+			return fx > fy
+		}
+	}
+	return x.row < y.row
+}
+
+func (path *path) numFixed() int {
+	n := 0
+	for _, seg := range path.segments {
+		if !seg.wildcard() {
+			n++
+		}
+	}
+	return n
+}
+
+// matchPath returns the path matching tokens in
+// the segment table t, per the precedence p.
+func (p Precedence) matchPath(tokens *pathTokens, t *segmentTable, budget *lookupBudget) *path {
+	if p == PrecedenceLeftToRight {
+		return t.findHandlePath(tokens, 0, 0, budget)
+	}
+	return t.findBestPath(tokens, 0, 0, nil, p, budget)
+}
+
+// findBestPath is like findHandlePath, but it explores all the
+// paths matching tokens and returns the one preceding the others,
+// including best, per the precedence p.
+func (t *segmentTable) findBestPath(tokens *pathTokens, col int, entry int32, best *path, p Precedence, budget *lookupBudget) *path {
+	better := func(i int32) {
+		if i < t.lastColumn {
+			best = t.findBestPath(tokens, col+1, i+t.numRows, best, p, budget)
+		} else if path := t.paths[i-t.lastColumn]; best == nil || p.precedes(path, best) {
+			best = path
+		}
+	}
+
+	if i := t.findFixedSegment(tokens.token(col), entry, budget); i != noSegment {
+		better(i)
+	}
+	if i := t.segments[entry].startWildcard; i != noSegment && budget.spend() {
+		better(i)
+	}
+	return best
+}
+
+// findFixedSegment returns the index of the first fixed segment in
+// the segment group starting at index entry which matches token.
+func (t *segmentTable) findFixedSegment(token string, entry int32, budget *lookupBudget) int32 {
+	if t.segments[entry].wideEnd != 0 {
+		return t.searchFixedSegment(token, entry, budget)
+	}
+	for i := entry; i != noSegment && i != t.segments[entry].startWildcard; {
+		if !budget.spend() {
+			return noSegment
+		}
+		switch seg := &t.segments[i]; {
+		case len(seg.token) < len(token):
+			i = seg.startLonger
+		case len(seg.token) > len(token):
+			return noSegment
+		case seg.token < token:
+			i = seg.startLarger
+		case seg.token > token:
+			return noSegment
+		default:
+			return i
+		}
+	}
+	return noSegment
+}
@@ -0,0 +1,38 @@
+package tinyrouter
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPrecedence(t *testing.T) {
+	handle := func(http.ResponseWriter, *http.Request) {}
+	routes := []Route{
+		{Method: "GET", Pattern: "/:who/b/c/d", HandleFunc: handle},
+		{Method: "GET", Pattern: "/a/:x/:y/d", HandleFunc: handle},
+		{Method: "GET", Pattern: "/a/:x/c/:z", HandleFunc: handle},
+	}
+
+	var testCases = []struct {
+		precedence Precedence
+		urlPath    string
+		pattern    string
+	}{
+		{PrecedenceLeftToRight, "/a/b/c/d", "/a/:x/c/:z"},
+		{PrecedenceLeftToRight, "/a/b/x/d", "/a/:x/:y/d"},
+		{PrecedenceMostFixed, "/a/b/c/d", "/:who/b/c/d"},
+		{PrecedenceMostFixed, "/a/x/c/d", "/a/:x/c/:z"},
+		{PrecedenceDeclarationOrder, "/a/b/c/d", "/:who/b/c/d"},
+		{PrecedenceDeclarationOrder, "/a/x/c/d", "/a/:x/:y/d"},
+	}
+	for _, tc := range testCases {
+		router := New(Config{Routes: routes, Precedence: tc.precedence})
+		if p, _ := router.Lookup("GET", tc.urlPath); p.Pattern() != tc.pattern {
+			t.Errorf("%v: %s matched %s, want %s", tc.precedence, tc.urlPath, p.Pattern(), tc.pattern)
+		}
+		if info := router.DumpInfo(); !strings.HasPrefix(info, "precedence: "+tc.precedence.String()) {
+			t.Errorf("%v: DumpInfo doesn't show the precedence:%s", tc.precedence, info)
+		}
+	}
+}
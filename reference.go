@@ -0,0 +1,104 @@
+package tinyrouter
+
+import "strings"
+
+// ReferenceLookup is a slow but straightforward implementation of
+// Lookup, for verifying the results of Lookup. It checks every route
+// against the request path, and selects the one with the highest
+// precedence among the matching ones:
+//
+//   - routes with the request method are tried before the ones with the
+//     fallback methods in the syntax of http.ServeMux (see Route);
+//   - routes without remainder wildcards are tried before the ones with;
+//   - among the routes with remainder wildcards, the ones with more
+//     segments are tried first;
+//   - among the other matching routes, the one preceding the others
+//     per Config.Precedence wins.
+func (tr *TinyRouter) ReferenceLookup(method, urlPath string) (Params, bool) {
+	if !strings.HasPrefix(urlPath, "/") {
+		return Params{}, false
+	}
+	urlPath = urlPath[1:]
+	if len(urlPath) > 1024 {
+		urlPath = urlPath[:1024]
+	}
+	tokens := strings.Split(urlPath, "/")
+	if !tr.serveMuxSyntax {
+		tokens = strings.SplitN(urlPath, "/", tr.maxNumTokens)
+	}
+
+	for _, method := range append([]string{method}, tr.fallbackMethods(method)...) {
+		if p, ok := tr.referenceFind(tr.pathsByMethod[method], tokens, false); ok {
+			return p, true
+		}
+		if p, ok := tr.referenceFind(tr.remainderPathsByMethod[method], tokens, true); ok {
+			return p, true
+		}
+	}
+	return Params{}, false
+}
+
+func (tr *TinyRouter) referenceFind(pathsByNumTokens *[maxSegmentsInPath][]*path, tokens []string, remainder bool) (Params, bool) {
+	if pathsByNumTokens == nil {
+		return Params{}, false
+	}
+
+	var best *path
+	var bestTokens []string
+	for _, paths := range pathsByNumTokens {
+		for _, path := range paths {
+			matched, ok := referenceMatch(path, tokens, remainder)
+			if !ok {
+				continue
+			}
+			if best == nil || len(matched) > len(bestTokens) ||
+				len(matched) == len(bestTokens) && tr.referencePrecedes(path, best) {
+				best, bestTokens = path, matched
+			}
+		}
+	}
+	if best == nil {
+		return Params{}, false
+	}
+	return paramsOf(best, strings.Join(bestTokens, "/")), true
+}
+
+// referenceMatch returns the tokens as Params holds them if path matches
+// tokens. For a remainder path, the last token holds the remaining path.
+func referenceMatch(path *path, tokens []string, remainder bool) ([]string, bool) {
+	n := len(path.segments)
+	if remainder {
+		if len(tokens) < n {
+			return nil, false
+		}
+		matched := append([]string(nil), tokens[:n-1]...)
+		tokens = append(matched, strings.Join(tokens[n-1:], "/"))
+	} else if len(tokens) != n {
+		return nil, false
+	}
+	for i, seg := range path.segments {
+		if !seg.wildcard() && seg.token != tokens[i] {
+			return nil, false
+		}
+	}
+	return tokens, true
+}
+
+// referencePrecedes reports whether or not path x precedes path y.
+// Both of them have the same number of segments.
+func (tr *TinyRouter) referencePrecedes(x, y *path) bool {
+	switch tr.precedence {
+	case PrecedenceDeclarationOrder:
+		return x.index < y.index
+	case PrecedenceMostFixed:
+		if fx, fy := x.numFixed(), y.numFixed(); fx != fy {
+			return fx > fy
+		}
+	}
+	for i, seg := range x.segments {
+		if seg.wildcard() != y.segments[i].wildcard() {
+			return !seg.wildcard()
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package tinyrouter
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// The fixed tokens used in the generated patterns and request paths.
+// The similar tokens exercise the startLarger and numSameBytes shortcuts.
+var fuzzTokens = []string{"", "a", "b", "aa", "ab", "ba", "abc", "abd", "bcd", "aab"}
+
+// fuzzRouter builds a router from data. Each route is encoded by
+// some bytes: the first one encodes the method, the number of
+// segments and whether or not the last segment is a remainder
+// wildcard, each of the following ones encodes a segment.
+// The patterns are in the syntax of http.ServeMux if serveMux is true,
+// and the routes conflicting with the preceding ones are then dropped.
+func fuzzRouter(data []byte, serveMux bool, precedence Precedence) (router *TinyRouter, patterns []string) {
+	seen := make(map[string]bool)
+	var routes []Route
+	for len(data) > 0 && len(routes) < 32 {
+		head := data[0]
+		n := int(head&3) + 1
+		if len(data) < n+1 {
+			break
+		}
+		method := "GET"
+		if head&4 != 0 {
+			method = ""
+		}
+		remainder := serveMux && head&8 != 0
+
+		var tokens, keys []string
+		for i, b := range data[1 : n+1] {
+			switch {
+			case remainder && i == n-1:
+				tokens = append(tokens, fmt.Sprintf("{p%d...}", i))
+				keys = append(keys, "**")
+			case int(b) >= len(fuzzTokens):
+				if serveMux {
+					tokens = append(tokens, fmt.Sprintf("{p%d}", i))
+				} else {
+					tokens = append(tokens, fmt.Sprintf(":p%d", i))
+				}
+				keys = append(keys, "*")
+			case serveMux && i == n-1 && fuzzTokens[b] == "":
+				tokens = append(tokens, "{$}")
+				keys = append(keys, "")
+			default:
+				tokens = append(tokens, fuzzTokens[b])
+				keys = append(keys, fuzzTokens[b])
+			}
+		}
+		data = data[n+1:]
+
+		key := method + " /" + strings.Join(keys, "/")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		pattern := "/" + strings.Join(tokens, "/")
+		patterns = append(patterns, method+" "+pattern)
+		routes = append(routes, Route{
+			Method:     method,
+			Pattern:    pattern,
+			HandleFunc: func(http.ResponseWriter, *http.Request) {},
+		})
+	}
+	for {
+		router, err := compile(Config{Routes: routes, ServeMuxSyntax: serveMux, Precedence: precedence})
+		var conflict *routeError
+		if !errors.As(err, &conflict) {
+			if err != nil {
+				panic(err)
+			}
+			return router, patterns
+		}
+		routes = slices.Delete(routes, conflict.index, conflict.index+1)
+		patterns = slices.Delete(patterns, conflict.index, conflict.index+1)
+	}
+}
+
+// randomBytes returns n random bytes for fuzzRouter and fuzzURLs,
+// most of which encode fuzzTokens.
+func randomBytes(rng *rand.Rand, n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(rng.Intn(len(fuzzTokens) + 3))
+	}
+	return data
+}
+
+// randomRouter builds the i-th router of a test from size random bytes by
+// fuzzRouter. The routers take turns in both syntaxes and all precedences,
+// and one in five of the ones with PrecedenceLeftToRight don't backtrack.
+func randomRouter(rng *rand.Rand, i, size int) *TinyRouter {
+	router, _ := fuzzRouter(randomBytes(rng, size), i%2 == 0, Precedence(i%3))
+	if i%5 == 0 && router.precedence == PrecedenceLeftToRight {
+		router.noBacktracking = true
+	}
+	return router
+}
+
+// fuzzURLs decodes request paths from data.
+// Each byte encodes a token, and 0xFF ends a path.
+func fuzzURLs(data []byte) []string {
+	var urls []string
+	var tokens []string
+	for _, b := range data {
+		if b == 0xFF {
+			urls = append(urls, "/"+strings.Join(tokens, "/"))
+			tokens = tokens[:0]
+		} else if int(b) < len(fuzzTokens) {
+			tokens = append(tokens, fuzzTokens[b])
+		} else {
+			tokens = append(tokens, fmt.Sprintf("x%d", b))
+		}
+	}
+	return append(urls, "/"+strings.Join(tokens, "/"))
+}
+
+func FuzzLookup(f *testing.F) {
+	f.Add([]byte{3, 1, 2, 3, 4, 3, 1, 20, 3, 4, 3, 20, 2, 3, 4, 3, 20, 20, 20, 20}, []byte{1, 2, 3, 4, 0xFF, 1, 9, 3, 4, 0xFF, 7, 2, 8}, false, uint8(0))
+	f.Add([]byte{1, 1, 4, 1, 7, 20, 20, 1, 20, 6, 2, 8, 9, 0, 1, 5}, []byte{1, 6, 0xFF, 1, 7, 0xFF, 8, 9, 0xFF, 1, 9}, false, uint8(1))
+	f.Add([]byte{9, 1, 20, 10, 1, 2, 20, 1, 0, 0, 4, 20}, []byte{1, 2, 3, 4, 0xFF, 1, 0xFF, 0xFF, 1, 2}, true, uint8(0))
+	f.Add([]byte{8, 1, 11, 1, 2, 20, 0, 0, 1, 20, 14, 20}, []byte{1, 0, 0xFF, 0, 0xFF, 5, 5, 5, 5, 5, 5}, true, uint8(2))
+	f.Add([]byte{3, 20, 2, 3, 4, 3, 1, 20, 20, 20, 3, 1, 2, 3, 20}, []byte{1, 2, 3, 4, 0xFF, 1, 2, 3, 9}, false, uint8(2))
+
+	f.Fuzz(func(t *testing.T, routeData, urlData []byte, serveMux bool, precedence uint8) {
+		router, patterns := fuzzRouter(routeData, serveMux, Precedence(precedence%3))
+		for _, url := range fuzzURLs(urlData) {
+			for _, method := range []string{"GET", "POST"} {
+				got, gotOk := router.Lookup(method, url)
+				want, wantOk := router.ReferenceLookup(method, url)
+				_, gotValues := got.ToMapAndSlice()
+				_, wantValues := want.ToMapAndSlice()
+				if gotOk != wantOk || got.Pattern() != want.Pattern() || !slices.Equal(gotValues, wantValues) {
+					t.Fatalf("%s %s:\n Lookup: %v %s %q\n ReferenceLookup: %v %s %q\nroutes:\n %s",
+						method, url, gotOk, got.Pattern(), gotValues, wantOk, want.Pattern(), wantValues,
+						strings.Join(patterns, "\n "))
+				}
+			}
+		}
+	})
+}
+
+// The segment groups with many fixed tokens are binary searched,
+// and must select the same routes as they are scanned.
+func TestWideSegmentGroups(t *testing.T) {
+	handle := func(w http.ResponseWriter, r *http.Request) {}
+	var tokens []string
+	for i := 0; i < 300; i++ {
+		tokens = append(tokens, fmt.Sprint(i*37%1000)) // tokens with different lengths
+	}
+	for _, precedence := range []Precedence{PrecedenceLeftToRight, PrecedenceMostFixed, PrecedenceDeclarationOrder} {
+		var routes []Route
+		for i, token := range tokens {
+			routes = append(routes,
+				Route{Method: "GET", Pattern: "/v1/" + token + "/:id", HandleFunc: handle},
+				Route{Method: "GET", Pattern: "/v1/" + token + "/:id/" + fuzzTokens[i%len(fuzzTokens)], HandleFunc: handle})
+			if i%3 == 0 {
+				routes = append(routes, Route{Method: "GET", Pattern: "/:version/" + token + "/x/a", HandleFunc: handle})
+			}
+		}
+		routes = append(routes,
+			Route{Method: "GET", Pattern: "/v1/:resource/:id", HandleFunc: handle},
+			Route{Method: "GET", Pattern: "/v1/:resource/:id/:action", HandleFunc: handle})
+		router := New(Config{Routes: routes, Precedence: precedence})
+		if table := router.tables.get("GET")[2]; table.segments[table.numRows].wideEnd == 0 {
+			t.Fatalf("the second column is not binary searched")
+		}
+
+		steps := router.Analyze().MaxWorstCaseSteps()
+		for i := -1; i <= 1000; i++ {
+			token := fmt.Sprint(i)
+			for _, url := range []string{"/v1/" + token + "/1", "/v1/" + token + "/x/a", "/v2/" + token + "/x/a", "/v1/" + token + "/1/aa"} {
+				got, _ := router.Lookup("GET", url)
+				want, _ := router.ReferenceLookup("GET", url)
+				if got.Pattern() != want.Pattern() {
+					t.Fatalf("%s: %s: Lookup: %s, ReferenceLookup: %s", precedence, url, got.Pattern(), want.Pattern())
+				}
+				if n := lookupSteps(router, url); n > steps {
+					t.Fatalf("%s: %s: %d steps are spent, at most %d are estimated", precedence, url, n, steps)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package tinyrouter
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A Reloader serves requests with a TinyRouter built from a route file
+// (see LoadRouter), the format of which is told by its extension (see
+// RouteFileFormatOf), which is rebuilt when the file is changed, so that
+// routing can be changed without restarting the program. A new router
+// is swapped in atomically, and the requests being served by the old
+// one are not affected. If a changed route file is invalid, the old
+// router is kept, and the error is reported by LastError.
+//
+// A Reloader is safe for concurrent use.
+type Reloader struct {
+	filename  string
+	format    RouteFileFormat
+	registry  map[string]http.HandlerFunc
+	configure func(*Config)
+
+	current atomic.Pointer[loadedRouter]
+
+	mu      sync.Mutex        // serializes reloads
+	modTime time.Time         // of the file the router is loaded from
+	size    int64             // of the file the router is loaded from
+	loaded  [sha256.Size]byte // the hash of the content of the router
+	failed  [sha256.Size]byte // the hash of the last invalid content
+	failure error             // the error of the last invalid content
+	lastErr error
+}
+
+// A loadedRouter is a router built by a Reloader, with its generation.
+// They are published together, so that the generation always tells
+// the router being used.
+type loadedRouter struct {
+	router     *TinyRouter
+	generation uint64
+}
+
+// NewReloader returns a Reloader with the router built from the route
+// file named filename, the handlers of which are looked up in registry.
+// If configure is not nil, it is called to set the other fields of the
+// loaded Config (such as OthersHandleFunc) before each router is built.
+// An error is returned if the route file can't be loaded.
+func NewReloader(filename string, registry map[string]http.HandlerFunc, configure func(*Config)) (*Reloader, error) {
+	r := &Reloader{filename: filename, format: RouteFileFormatOf(filename), registry: registry, configure: configure}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ServeHTTP lets *Reloader implement http.Handler interface.
+// Requests are served by the current router.
+func (r *Reloader) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.current.Load().router.ServeHTTP(w, req)
+}
+
+// Router returns the current router.
+func (r *Reloader) Router() *TinyRouter {
+	return r.current.Load().router
+}
+
+// Generation returns how many routers have been built, including the
+// initial one. It increases by one each time a new router is swapped in.
+// To get the router of a generation, use RouterGeneration.
+func (r *Reloader) Generation() uint64 {
+	return r.current.Load().generation
+}
+
+// RouterGeneration returns the current router and its generation.
+func (r *Reloader) RouterGeneration() (*TinyRouter, uint64) {
+	current := r.current.Load()
+	return current.router, current.generation
+}
+
+// LastError returns the error of the last reload, which is nil if
+// the last reload succeeded or found the route file not changed.
+func (r *Reloader) LastError() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastErr
+}
+
+// Reload reads the route file and swaps in a new router built from it,
+// if its content is changed. It is useful to reload on signals, such as
+// SIGHUP. The returned error is also reported by LastError.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastErr = r.reload(false)
+	return r.lastErr
+}
+
+// Watch polls the route file every interval until ctx is done, and reloads
+// it when it is changed. The modification time and size of the file are
+// checked first, and the content is compared only if they differ from
+// the ones of the file the current router is loaded from, so that a file
+// rewritten with the same content doesn't cause rebuilding, and an invalid
+// file is read again in each poll, but not rebuilt until it is changed.
+// Watch blocks, so it is usually called in a new goroutine.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		r.mu.Lock()
+		if info, err := os.Stat(r.filename); err != nil {
+			r.lastErr = err
+		} else if !info.ModTime().Equal(r.modTime) || info.Size() != r.size {
+			r.lastErr = r.reload(true)
+		}
+		r.mu.Unlock()
+	}
+}
+
+// reload is the implementation of Reload. If polling is true, the
+// content which failed in the last reload is not tried again.
+// r.mu must be held.
+func (r *Reloader) reload(polling bool) error {
+	f, err := os.Open(r.filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(data)
+	current := r.current.Load()
+	if current != nil {
+		if hash == r.loaded {
+			r.modTime, r.size = info.ModTime(), info.Size()
+			return nil
+		}
+		if polling && hash == r.failed {
+			return r.failure
+		}
+	}
+
+	router, err := r.build(data)
+	if err != nil {
+		r.failed, r.failure = hash, err
+		return err
+	}
+
+	generation := uint64(1)
+	if current != nil {
+		generation = current.generation + 1
+	}
+	r.current.Store(&loadedRouter{router, generation})
+	r.loaded = hash
+	r.modTime, r.size = info.ModTime(), info.Size()
+	return nil
+}
+
+// build builds a router from the content of a route file.
+func (r *Reloader) build(data []byte) (*TinyRouter, error) {
+	return LoadRouter(bytes.NewReader(data), r.format, r.registry, r.configure)
+}
@@ -0,0 +1,164 @@
+package tinyrouter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReloader(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "routes.json")
+	writeRoutes := func(routes ...string) {
+		t.Helper()
+		content := "{\"routes\": [\n" + strings.Join(routes, ",\n") + "\n]}"
+		if err := os.WriteFile(filename, []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	registry := map[string]http.HandlerFunc{
+		"a": func(w http.ResponseWriter, req *http.Request) { w.Write([]byte("a")) },
+		"b": func(w http.ResponseWriter, req *http.Request) { w.Write([]byte("b")) },
+	}
+	notFound := func(w http.ResponseWriter, req *http.Request) { w.Write([]byte("-")) }
+	configure := func(c *Config) { c.OthersHandleFunc = notFound }
+
+	if _, err := NewReloader(filename, registry, configure); err == nil {
+		t.Fatalf("NewReloader succeeds without the route file")
+	}
+
+	writeRoutes(`{"method": "GET", "pattern": "/x", "handler": "a"}`)
+	r, err := NewReloader(filename, registry, configure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(generation uint64, want string) {
+		t.Helper()
+		if router, got := r.RouterGeneration(); got != generation || r.Generation() != generation || router != r.Router() {
+			t.Errorf("generation %d, want %d", got, generation)
+		}
+		var got []string
+		for _, url := range []string{"/x", "/y"} {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+			got = append(got, w.Body.String())
+		}
+		if strings.Join(got, " ") != want {
+			t.Errorf("/x and /y are served by %q, want %q", got, want)
+		}
+	}
+	check(1, "a -")
+
+	// A valid change is swapped in.
+	writeRoutes(`{"method": "GET", "pattern": "/x", "handler": "b"}`, `{"method": "GET", "pattern": "/y", "handler": "a"}`)
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	check(2, "b a")
+
+	// The same content is not rebuilt.
+	if err := r.Reload(); err != nil || r.LastError() != nil {
+		t.Fatalf("reloading the same content: %v", err)
+	}
+	check(2, "b a")
+
+	// An invalid change is rejected, and the old router is kept.
+	writeRoutes(`{"method": "GET", "pattern": "/x", "handler": "b"}`, `{"method": "GET", "pattern": "/y", "handler": "c"}`)
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filename, past, past); err != nil {
+		t.Fatal(err)
+	}
+	err = r.Reload()
+	if want := `tinyrouter: line 3: unknown handler "c"`; err == nil || err.Error() != want || r.LastError() != err {
+		t.Fatalf("Reload returns %v and LastError returns %v, want %s", err, r.LastError(), want)
+	}
+	check(2, "b a")
+
+	// Watch reloads the fixed route file, even if its modification
+	// time and size are the same as the rejected one.
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.Watch(ctx, time.Millisecond)
+	}()
+	// Some requests are served concurrently during the reloading.
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/x", nil))
+			}
+		}()
+	}
+
+	writeRoutes(`{"method": "GET", "pattern": "/x", "handler": "a"}`, `{"method": "GET", "pattern": "/y", "handler": "b"}`)
+	if err := os.Chtimes(filename, past, past); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(10 * time.Second); r.Generation() < 3 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	wg.Wait()
+	if err := r.LastError(); err != nil {
+		t.Errorf("LastError returns %v after the route file is fixed", err)
+	}
+	check(3, "a b")
+}
+
+// The lookup results cached by the old router are not used after
+// reloading. The route file is in TOML, told by its extension.
+func TestReloaderLookupCache(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "routes.toml")
+	writeRoute := func(pattern, handler string) {
+		t.Helper()
+		content := "[[routes]]\nmethod = \"GET\"\npattern = \"" + pattern + "\"\nhandler = \"" + handler + "\"\n"
+		if err := os.WriteFile(filename, []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	registry := map[string]http.HandlerFunc{
+		"a": func(w http.ResponseWriter, req *http.Request) { w.Write([]byte("a:" + PathParams(req).Value("id"))) },
+		"b": func(w http.ResponseWriter, req *http.Request) { w.Write([]byte("b:" + PathParams(req).Value("id"))) },
+	}
+
+	writeRoute("/items/:id", "a")
+	r, err := NewReloader(filename, registry, func(c *Config) { c.LookupCacheSize = 8 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve := func(want string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/items/1", nil))
+		if got := w.Body.String(); got != want {
+			t.Errorf("/items/1 is served by %q, want %q", got, want)
+		}
+	}
+	serve("a:1")
+	serve("a:1")
+	if stats := r.Router().CacheStats(); stats.Hits != 1 || stats.Entries != 1 {
+		t.Fatalf("CacheStats() = %+v before reloading", stats)
+	}
+
+	writeRoute("/:id/1", "b")
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if stats := r.Router().CacheStats(); stats != (CacheStats{Size: 8}) {
+		t.Errorf("CacheStats() = %+v after reloading", stats)
+	}
+	serve("b:items")
+	serve("b:items")
+	if stats := r.Router().CacheStats(); stats.Hits != 1 || stats.Entries != 1 {
+		t.Errorf("CacheStats() = %+v after reloading", stats)
+	}
+}
@@ -0,0 +1,287 @@
+package tinyrouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// A RouteFile declares a routing table in JSON, YAML or TOML, with the
+// handlers being referred to by names. For example, in JSON,
+//
+//	{
+//		"routes": [
+//			{"method": "GET", "pattern": "/users/:name", "handler": "getUser", "name": "user"},
+//			{"method": "POST", "pattern": "/users", "handler": "createUser"},
+//			{"method": "GET", "pattern": "/blog", "handler": "redirect", "metadata": {"to": "https://blog.example.com"}}
+//		]
+//	}
+//
+// The same fields are used in YAML and TOML. The routes in a TOML file are
+// declared as an array of tables, so that the errors in them can report
+// their line numbers:
+//
+//	[[routes]]
+//	method = "GET"
+//	pattern = "/users/:name"
+//	handler = "getUser"
+//
+// Route files are loaded by LoadConfig and LoadRouter, and read by the
+// tinyrouter-gen command (see Generate).
+type RouteFile struct {
+	// Whether or not the patterns are in the syntax of http.ServeMux.
+	// See Config.ServeMuxSyntax.
+	ServeMuxSyntax bool `json:"serveMuxSyntax,omitempty" yaml:"serveMuxSyntax,omitempty" toml:"serveMuxSyntax,omitempty"`
+
+	Routes []RouteDecl `json:"routes" yaml:"routes" toml:"routes"`
+}
+
+// A RouteDecl declares a route in a RouteFile. See Route.
+type RouteDecl struct {
+	Method  string `json:"method,omitempty" yaml:"method,omitempty" toml:"method,omitempty"`
+	Pattern string `json:"pattern" yaml:"pattern" toml:"pattern"`
+
+	// The name of the handler function of the route.
+	Handler string `json:"handler" yaml:"handler" toml:"handler"`
+
+	Name     string            `json:"name,omitempty" yaml:"name,omitempty" toml:"name,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty" toml:"metadata,omitempty"`
+}
+
+func (d RouteDecl) route(handle http.HandlerFunc) Route {
+	return Route{Method: d.Method, Pattern: d.Pattern, HandleFunc: handle, Name: d.Name, Metadata: d.Metadata}
+}
+
+// A RouteFileFormat is the format of a route file.
+type RouteFileFormat int
+
+const (
+	RouteFileJSON RouteFileFormat = iota
+	RouteFileYAML
+	RouteFileTOML
+)
+
+// RouteFileFormatOf returns the format of the route file named filename
+// by its extension: YAML for ".yaml" and ".yml", TOML for ".toml",
+// and JSON for the others.
+func RouteFileFormatOf(filename string) RouteFileFormat {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return RouteFileYAML
+	case ".toml":
+		return RouteFileTOML
+	}
+	return RouteFileJSON
+}
+
+// ReadRouteFile decodes a RouteFile in format from r.
+// Syntax errors report the line numbers.
+func ReadRouteFile(r io.Reader, format RouteFileFormat) (RouteFile, error) {
+	f, _, err := readRouteFile(r, format)
+	return f, err
+}
+
+// readRouteFile is the same as ReadRouteFile, except it also returns
+// the line numbers of the declared routes, which are nil if unknown.
+func readRouteFile(r io.Reader, format RouteFileFormat) (RouteFile, []int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return RouteFile{}, nil, fmt.Errorf("tinyrouter: reading route file: %w", err)
+	}
+	switch format {
+	case RouteFileYAML:
+		return readYAMLRouteFile(data)
+	case RouteFileTOML:
+		return readTOMLRouteFile(data)
+	}
+	return readJSONRouteFile(data)
+}
+
+// readJSONRouteFile decodes a JSON route file. The syntax errors
+// and type errors are reported with the line numbers.
+func readJSONRouteFile(data []byte) (RouteFile, []int, error) {
+	var f RouteFile
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&f); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			return RouteFile{}, nil, fmt.Errorf("tinyrouter: line %d: %w", lineAt(data, syntaxErr.Offset), err)
+		case errors.As(err, &typeErr):
+			return RouteFile{}, nil, fmt.Errorf("tinyrouter: line %d: %w", lineAt(data, typeErr.Offset), err)
+		}
+		return RouteFile{}, nil, fmt.Errorf("tinyrouter: decoding route file: %w", err)
+	}
+	return f, jsonRouteLines(data), nil
+}
+
+// jsonRouteLines returns the line numbers of the routes
+// in data, which is a valid JSON route file.
+func jsonRouteLines(data []byte) []int {
+	d := json.NewDecoder(bytes.NewReader(data))
+	if _, err := d.Token(); err != nil { // {
+		return nil
+	}
+	for d.More() {
+		key, err := d.Token()
+		if err != nil {
+			return nil
+		}
+		if key != "routes" {
+			var v json.RawMessage
+			if d.Decode(&v) != nil {
+				return nil
+			}
+			continue
+		}
+		if t, err := d.Token(); err != nil || t != json.Delim('[') {
+			return nil
+		}
+		var lines []int
+		for d.More() {
+			// The input offset is at the end of the previous token.
+			lines = append(lines, lineAt(data, d.InputOffset()))
+			var v json.RawMessage
+			if d.Decode(&v) != nil {
+				return nil
+			}
+		}
+		return lines
+	}
+	return nil
+}
+
+// lineAt returns the line number of the first
+// token at or after the offset in data.
+func lineAt(data []byte, offset int64) int {
+	offset = min(offset, int64(len(data)))
+	for offset < int64(len(data)) && bytes.IndexByte([]byte(" \t\r\n,"), data[offset]) >= 0 {
+		offset++
+	}
+	return 1 + bytes.Count(data[:offset], []byte("\n"))
+}
+
+// readYAMLRouteFile decodes a YAML route file. The errors of yaml.v3
+// report the line numbers.
+func readYAMLRouteFile(data []byte) (RouteFile, []int, error) {
+	var f RouteFile
+	d := yaml.NewDecoder(bytes.NewReader(data))
+	d.KnownFields(true)
+	if err := d.Decode(&f); err != nil {
+		return RouteFile{}, nil, fmt.Errorf("tinyrouter: decoding route file: %w", err)
+	}
+
+	var doc yaml.Node
+	if yaml.Unmarshal(data, &doc) != nil || len(doc.Content) == 0 {
+		return f, nil, nil
+	}
+	m := doc.Content[0]
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value != "routes" {
+			continue
+		}
+		var lines []int
+		for _, route := range m.Content[i+1].Content {
+			lines = append(lines, route.Line)
+		}
+		return f, lines, nil
+	}
+	return f, nil, nil
+}
+
+// readTOMLRouteFile decodes a TOML route file. The errors of toml
+// report the line numbers. The line numbers of the routes are found
+// only if they are declared as an array of tables.
+func readTOMLRouteFile(data []byte) (RouteFile, []int, error) {
+	var f RouteFile
+	md, err := toml.NewDecoder(bytes.NewReader(data)).Decode(&f)
+	if err != nil {
+		return RouteFile{}, nil, fmt.Errorf("tinyrouter: decoding route file: %w", err)
+	}
+	if keys := md.Undecoded(); len(keys) > 0 {
+		return RouteFile{}, nil, fmt.Errorf("tinyrouter: decoding route file: toml: unknown key %q", keys[0].String())
+	}
+
+	var lines []int
+	for i, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		if strings.ReplaceAll(strings.TrimSpace(line), " ", "") == "[[routes]]" {
+			lines = append(lines, i+1)
+		}
+	}
+	if len(lines) != len(f.Routes) {
+		return f, nil, nil
+	}
+	return f, lines, nil
+}
+
+// LoadConfig reads a route file (see RouteFile) in format from r, and
+// returns the Config with the declared routes, the handlers of which are
+// looked up in registry by the handler names. The routes are validated the
+// same way as New does, and the errors report the line numbers in the
+// route file. Other fields of the returned Config may be set before
+// calling New.
+func LoadConfig(r io.Reader, format RouteFileFormat, registry map[string]http.HandlerFunc) (Config, error) {
+	c, _, err := loadRouter(r, format, registry, nil)
+	return c, err
+}
+
+// LoadRouter is the same as LoadConfig, except it returns the router built
+// from the loaded Config, the other fields of which are set by configure
+// (if it is not nil) before the router is built. The router remembers the
+// handler names of the routes, so that Diff compares the handlers by them.
+// The handlers of the routes added by configure are compared by their code.
+func LoadRouter(r io.Reader, format RouteFileFormat, registry map[string]http.HandlerFunc, configure func(*Config)) (*TinyRouter, error) {
+	_, tr, err := loadRouter(r, format, registry, configure)
+	return tr, err
+}
+
+// loadRouter is the implementation of LoadConfig and LoadRouter.
+func loadRouter(r io.Reader, format RouteFileFormat, registry map[string]http.HandlerFunc, configure func(*Config)) (Config, *TinyRouter, error) {
+	f, lines, err := readRouteFile(r, format)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	where := func(i int) string {
+		if i < len(lines) {
+			return fmt.Sprintf("line %d", lines[i])
+		}
+		return fmt.Sprintf("route #%d", i+1) // such as in an inline TOML array
+	}
+
+	c := Config{ServeMuxSyntax: f.ServeMuxSyntax, Routes: make([]Route, len(f.Routes))}
+	for i, d := range f.Routes {
+		handle := registry[d.Handler]
+		if handle == nil {
+			return Config{}, nil, fmt.Errorf("tinyrouter: %s: unknown handler %q", where(i), d.Handler)
+		}
+		c.Routes[i] = d.route(handle)
+	}
+	if configure != nil {
+		configure(&c)
+	}
+
+	tr, err := compile(c)
+	if err != nil {
+		var re *routeError
+		if errors.As(err, &re) {
+			return Config{}, nil, fmt.Errorf("tinyrouter: %s: %w", where(re.index), re.err)
+		}
+		return Config{}, nil, fmt.Errorf("tinyrouter: %w", err)
+	}
+	tr.handlerNames = make([]string, len(tr.routes)) // the routes added by configure have no names
+	for i, d := range f.Routes[:min(len(f.Routes), len(tr.routes))] {
+		tr.handlerNames[i] = d.Handler
+	}
+	return c, tr, nil
+}
@@ -0,0 +1,173 @@
+package tinyrouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadRouteFile(t *testing.T) {
+	want := RouteFile{ServeMuxSyntax: true, Routes: []RouteDecl{
+		{Pattern: "GET /{$}", Handler: "home", Name: "home"},
+		{Method: "GET", Pattern: "/blog", Handler: "redirect", Metadata: map[string]string{"to": "/posts"}},
+	}}
+	files := []struct {
+		format  RouteFileFormat
+		content string
+	}{
+		{RouteFileJSON, `{"serveMuxSyntax": true, "routes": [
+			{"pattern": "GET /{$}", "handler": "home", "name": "home"},
+			{"method": "GET", "pattern": "/blog", "handler": "redirect", "metadata": {"to": "/posts"}}
+		]}`},
+		{RouteFileYAML, `
+serveMuxSyntax: true
+routes:
+  - {pattern: "GET /{$}", handler: home, name: home}
+  - method: GET
+    pattern: /blog
+    handler: redirect
+    metadata: {to: /posts}
+`},
+		{RouteFileTOML, `
+serveMuxSyntax = true
+
+[[routes]]
+pattern = "GET /{$}"
+handler = "home"
+name = "home"
+
+[[routes]]
+method = "GET"
+pattern = "/blog"
+handler = "redirect"
+metadata = {to = "/posts"}
+`},
+	}
+	for _, file := range files {
+		f, err := ReadRouteFile(strings.NewReader(file.content), file.format)
+		if err != nil {
+			t.Errorf("ReadRouteFile(%q): %v", file.content, err)
+		} else if !reflect.DeepEqual(f, want) {
+			t.Errorf("ReadRouteFile(%q) got %+v, want %+v", file.content, f, want)
+		}
+	}
+
+	unknown := []struct {
+		format  RouteFileFormat
+		content string
+	}{
+		{RouteFileJSON, `{"routes": [{"path": "/"}]}`},
+		{RouteFileYAML, "routes:\n  - path: /\n"},
+		{RouteFileTOML, "[[routes]]\npath = \"/\"\n"},
+	}
+	for _, file := range unknown {
+		if _, err := ReadRouteFile(strings.NewReader(file.content), file.format); err == nil {
+			t.Errorf("ReadRouteFile(%q) accepts unknown fields", file.content)
+		}
+	}
+}
+
+func TestRouteFileFormatOf(t *testing.T) {
+	for filename, want := range map[string]RouteFileFormat{
+		"routes.json":      RouteFileJSON,
+		"routes":           RouteFileJSON,
+		"conf/routes.yaml": RouteFileYAML,
+		"routes.YML":       RouteFileYAML,
+		"routes.toml":      RouteFileTOML,
+	} {
+		if got := RouteFileFormatOf(filename); got != want {
+			t.Errorf("RouteFileFormatOf(%q) = %v, want %v", filename, got, want)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	redirect := func(w http.ResponseWriter, req *http.Request) {
+		http.Redirect(w, req, PathParams(req).RouteMetadata("to"), http.StatusFound)
+	}
+	getUser := func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(PathParams(req).RouteName() + ":" + PathParams(req).Value("name")))
+	}
+	registry := map[string]http.HandlerFunc{"redirect": redirect, "getUser": getUser}
+
+	c, err := LoadConfig(strings.NewReader(`{
+	"routes": [
+		{"method": "GET", "pattern": "/users/:name", "handler": "getUser", "name": "user"},
+		{"method": "GET", "pattern": "/blog", "handler": "redirect", "metadata": {"to": "https://blog.example.com"}}
+	]
+}`), RouteFileJSON, registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Routes) != 2 || c.Routes[0].Name != "user" || c.Routes[1].Metadata["to"] != "https://blog.example.com" || c.ServeMuxSyntax {
+		t.Fatalf("LoadConfig got %+v", c)
+	}
+
+	router := New(c)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/blog", nil))
+	if got := w.Header().Get("Location"); w.Code != http.StatusFound || got != "https://blog.example.com" {
+		t.Errorf("/blog is redirected to %q with status %d", got, w.Code)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/users/alice", nil))
+	if got := w.Body.String(); got != "user:alice" {
+		t.Errorf("/users/alice is served with %q", got)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	registry := map[string]http.HandlerFunc{"h": func(http.ResponseWriter, *http.Request) {}}
+	cases := []struct {
+		format     RouteFileFormat
+		file, want string
+	}{
+		{RouteFileJSON, "{\n\t\"routes\": [\n\t\t{\"pattern\": \"/\", \"handler\": \"h\"}\n\t\t{\"pattern\": \"/a\"}\n\t]\n}",
+			"tinyrouter: line 4: invalid character '{' after array element"},
+		{RouteFileJSON, "{\n\t\"routes\": [\n\t\t{\"pattern\": 1, \"handler\": \"h\"}\n\t]\n}",
+			"tinyrouter: line 3: json: cannot unmarshal number into Go struct field"},
+		{RouteFileJSON, `{"routes": [{"pattern": "/", "handler": "h"}], "serveMux": true}`,
+			`tinyrouter: decoding route file: json: unknown field "serveMux"`},
+		{RouteFileJSON, "{\n\t\"routes\": [\n\t\t{\"pattern\": \"/\", \"handler\": \"h\"},\n\t\t{\"pattern\": \"/a\", \"handler\": \"x\"}\n\t]\n}",
+			`tinyrouter: line 4: unknown handler "x"`},
+		{RouteFileJSON, "{\"routes\": [\n{\"pattern\": \"/\", \"handler\": \"h\"},\n\n{\"pattern\": \"a\", \"handler\": \"h\"}\n]}",
+			"tinyrouter: line 4: a pattern shell start with a slash: a"},
+		{RouteFileJSON, "{\"routes\": [\n{\"pattern\": \"/:a\", \"handler\": \"h\"}, {\"pattern\": \"/x\", \"handler\": \"h\"},\n{\"pattern\": \"/:b\", \"handler\": \"h\"}\n]}",
+			"tinyrouter: line 3: Equal paths are not allowed"},
+		{RouteFileJSON, "{\"routes\": [\n{\"pattern\": \"/a\", \"handler\": \"h\", \"name\": \"a\"},\n{\"pattern\": \"/b\", \"handler\": \"h\", \"name\": \"a\"}\n]}",
+			"tinyrouter: line 3: duplicated route name [a] for pattern: /b"},
+		{RouteFileJSON, "{\"serveMuxSyntax\": true, \"routes\": [\n{\"method\": \"GET\", \"pattern\": \"POST /a\", \"handler\": \"h\"}\n]}",
+			"tinyrouter: line 2: conflicted methods GET and POST for pattern: POST /a"},
+
+		{RouteFileYAML, "routes:\n  - pattern: /\n    handler: h\n  - [/a]\n",
+			"tinyrouter: decoding route file: yaml: unmarshal errors:\n  line 4: cannot unmarshal !!seq"},
+		{RouteFileYAML, "routes:\n  - pattern: /\n    handler: h\n  - pattern: /a\n    handler: x\n",
+			`tinyrouter: line 4: unknown handler "x"`},
+		{RouteFileYAML, "routes:\n  - {pattern: /:a, handler: h}\n\n  - {pattern: /:b, handler: h}\n",
+			"tinyrouter: line 4: Equal paths are not allowed"},
+		{RouteFileTOML, "[[routes]]\npattern = \"/\"\nhandler = h\n",
+			"tinyrouter: decoding route file: toml: line 3"},
+		{RouteFileTOML, "[[routes]]\npattern = \"/\"\nhandler = \"h\"\n\n[[routes]]\npattern = \"a\"\nhandler = \"h\"\n",
+			"tinyrouter: line 5: a pattern shell start with a slash: a"},
+		{RouteFileTOML, "routes = [\n{pattern = \"/\", handler = \"h\"},\n{pattern = \"/a\", handler = \"x\"},\n]\n",
+			`tinyrouter: route #2: unknown handler "x"`},
+	}
+	for _, c := range cases {
+		_, err := LoadConfig(strings.NewReader(c.file), c.format, registry)
+		if err == nil || !strings.HasPrefix(err.Error(), c.want) {
+			t.Errorf("LoadConfig(%q) got error %v, want %q", c.file, err, c.want)
+		}
+	}
+}
+
+func TestNewPanics(t *testing.T) {
+	h := func(http.ResponseWriter, *http.Request) {}
+	defer func() {
+		if v := recover(); v == nil || !strings.Contains(v.(error).Error(), "duplicated route name [a]") {
+			t.Errorf("New panics with %v", v)
+		}
+	}()
+	New(Config{Routes: []Route{{Pattern: "/a", HandleFunc: h, Name: "a"}, {Pattern: "/b", HandleFunc: h, Name: "a"}}})
+}
@@ -0,0 +1,356 @@
+package tinyrouter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// parseServeMuxPattern parses the pattern of r, which is in the syntax
+// of http.ServeMux, "[METHOD ]/[PATH]". The method in the pattern, if
+// there is one, is stored in r.Method. Only one path is returned.
+func parseServeMuxPattern(r *Route) ([]*path, error) {
+	pattern := r.Pattern
+	if i := strings.IndexAny(pattern, " \t"); i >= 0 {
+		method := pattern[:i]
+		if r.Method != "" && r.Method != method {
+			return nil, errors.New("conflicted methods " + r.Method + " and " + method + " for pattern: " + r.Pattern)
+		}
+		r.Method = method
+		pattern = strings.TrimLeft(pattern[i+1:], " \t")
+	}
+	if i := strings.IndexByte(pattern, '/'); i > 0 {
+		return nil, errors.New("host patterns are not supported: " + r.Pattern)
+	} else if i < 0 {
+		return nil, errors.New("a pattern shell start with a slash: " + r.Pattern)
+	}
+
+	// Translate the tokens into the default syntax.
+	tokens := strings.Split(pattern[1:], "/")
+	remainder, anonymous := false, false
+	last := len(tokens) - 1
+	for i, token := range tokens {
+		switch {
+		case !strings.ContainsAny(token, "{}"):
+			if i == last && token == "" { // a pattern ending with a slash
+				remainder, anonymous = true, true
+				tokens[i] = ":"
+			} else if strings.HasPrefix(token, ":") || strings.HasPrefix(token, `\`) {
+				tokens[i] = `\` + token
+			}
+		case token == "{$}":
+			if i != last {
+				return nil, errors.New("{$} is only allowed at the end of a pattern: " + r.Pattern)
+			}
+			tokens[i] = ""
+		case token[0] == '{' && token[len(token)-1] == '}':
+			name := token[1 : len(token)-1]
+			if strings.HasSuffix(name, "...") {
+				if i != last {
+					return nil, errors.New("{" + name + "} is only allowed at the end of a pattern: " + r.Pattern)
+				}
+				name = name[:len(name)-len("...")]
+				remainder = true
+			}
+			if !isIdentifier(name) {
+				return nil, errors.New("bad wildcard name [" + name + "] in " + r.Pattern)
+			}
+			tokens[i] = ":" + name
+		default:
+			return nil, errors.New("a wildcard must be a full segment: " + r.Pattern)
+		}
+	}
+
+	paths, err := parseTokens(*r, tokens)
+	if err != nil {
+		return nil, err
+	}
+	path := paths[0]
+	path.remainder = remainder
+	if anonymous {
+		path.wildcards = path.wildcards[:len(path.wildcards)-1]
+		path.numParams--
+	}
+	return paths, nil
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if !unicode.IsLetter(c) && c != '_' && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// A relationship is between two routes in the syntax of http.ServeMux,
+// by the sets of the requests they match.
+type relationship int
+
+const (
+	sameRequests relationship = iota // both match the same requests
+	moreGeneral                      // x matches all the requests y matches, and more
+	moreSpecific                     // y matches all the requests x matches, and more
+	disjoint                         // no requests are matched by both
+	overlapping                      // some requests are matched by both, but neither is more specific
+)
+
+// inverse returns the relationship of y to x if x has the relationship rel to y.
+func (rel relationship) inverse() relationship {
+	switch rel {
+	case moreGeneral:
+		return moreSpecific
+	case moreSpecific:
+		return moreGeneral
+	}
+	return rel
+}
+
+// combineRelationships returns the relationship of x to y, given the
+// relationships of two parts of them, such as the methods and the paths.
+func combineRelationships(r1, r2 relationship) relationship {
+	switch r1 {
+	case sameRequests:
+		return r2
+	case disjoint:
+		return disjoint
+	case overlapping:
+		if r2 == disjoint {
+			return disjoint
+		}
+		return overlapping
+	}
+	switch r2 {
+	case sameRequests:
+		return r1
+	case r1.inverse():
+		return overlapping
+	}
+	return r2
+}
+
+// relateMethods returns the relationship of the routes with method x
+// to the ones with method y. A blank method matches any methods, and
+// GET also matches HEAD.
+func relateMethods(x, y string) relationship {
+	switch {
+	case x == y:
+		return sameRequests
+	case x == "":
+		return moreGeneral
+	case y == "":
+		return moreSpecific
+	case x == http.MethodGet && y == http.MethodHead:
+		return moreGeneral
+	case x == http.MethodHead && y == http.MethodGet:
+		return moreSpecific
+	}
+	return disjoint
+}
+
+// relatePaths returns the relationship of path x to path y. A remainder
+// wildcard matches one or more tokens, so it is more general than the
+// rest of a longer path.
+func relatePaths(x, y *path) relationship {
+	nx, ny := len(x.segments), len(y.segments)
+	if nx != ny && !x.remainder && !y.remainder {
+		return disjoint
+	}
+	rel := sameRequests
+	for i := 0; i < min(nx, ny); i++ {
+		rel = combineRelationships(rel, relateSegments(x, y, i))
+		if rel == disjoint {
+			return disjoint
+		}
+	}
+	switch {
+	case nx == ny:
+		return rel
+	case nx < ny && x.remainder:
+		return combineRelationships(rel, moreGeneral)
+	case ny < nx && y.remainder:
+		return combineRelationships(rel, moreSpecific)
+	}
+	return disjoint
+}
+
+// relateSegments returns the relationship of the segments at column col
+// in path x and path y.
+//
+// Like http.ServeMux, a wildcard is taken as not matching the empty token
+// of "{$}", though it matches empty tokens in lookups (see Route). So
+// "/users/{$}" and "POST /users/{id}" don't conflict, but unlike by
+// http.ServeMux, "POST /users/" is routed to the latter, which has the
+// exact method.
+func relateSegments(x, y *path, col int) relationship {
+	sx, sy := x.segments[col], y.segments[col]
+	rx := x.remainder && col == len(x.segments)-1
+	ry := y.remainder && col == len(y.segments)-1
+	switch {
+	case rx && ry:
+		return sameRequests
+	case rx:
+		return moreGeneral
+	case ry:
+		return moreSpecific
+	case sx.wildcard() && sy.wildcard():
+		return sameRequests
+	case sx.wildcard():
+		if endsWithDollar(y, col) {
+			return disjoint
+		}
+		return moreGeneral
+	case sy.wildcard():
+		if endsWithDollar(x, col) {
+			return disjoint
+		}
+		return moreSpecific
+	case sx.token == sy.token:
+		return sameRequests
+	}
+	return disjoint
+}
+
+// endsWithDollar reports whether the segment at column col in path p
+// is the empty token translated from "{$}".
+func endsWithDollar(p *path, col int) bool {
+	return col == len(p.segments)-1 && !p.remainder && p.segments[col].token == ""
+}
+
+// checkConflicts returns an error for the first pair of routes which
+// conflict in the way http.ServeMux panics for: some requests are matched
+// by both of them, but neither of them is more specific than the other.
+// Without conflicts, the routes matching a request are ordered by their
+// specificities, and the most specific one is the one selected by lookups
+// with PrecedenceLeftToRight.
+//
+// Only the paths which may match some same requests are compared, which
+// are found in the conflictBuckets by the methods, the numbers of segments
+// and the first tokens.
+func (tr *TinyRouter) checkConflicts() error {
+	type methodPath struct {
+		method string
+		path   *path
+	}
+	var paths []methodPath
+	for _, groups := range []map[string]*[maxSegmentsInPath][]*path{tr.pathsByMethod, tr.remainderPathsByMethod} {
+		for method, pathsByNumTokens := range groups {
+			for _, group := range pathsByNumTokens {
+				for _, path := range group {
+					paths = append(paths, methodPath{method, path})
+				}
+			}
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return paths[i].path.index < paths[j].path.index
+	})
+
+	// The paths declared before the one being checked.
+	buckets := make(map[string]*[maxSegmentsInPath][2]conflictBucket)
+	var methods []string
+	for _, y := range paths {
+		var x *path // the first declared path conflicting with y
+		check := func(method string, b *conflictBucket) {
+			b.each(y.path, func(p *path) {
+				if x != nil && x.index < p.index {
+					return
+				}
+				rel := relateMethods(method, y.method)
+				if rel != disjoint {
+					rel = combineRelationships(rel, relatePaths(p, y.path))
+				}
+				if rel == overlapping {
+					x = p
+				}
+			})
+		}
+		ny := len(y.path.segments)
+		for _, method := range methods {
+			if relateMethods(method, y.method) == disjoint {
+				continue
+			}
+			bucketsByNumSegments := buckets[method]
+			for n := 1; n <= maxSegmentsInPath; n++ {
+				// Paths with different numbers of segments only match some
+				// same requests if the shorter one ends with a remainder.
+				if n == ny || n > ny && y.path.remainder {
+					check(method, &bucketsByNumSegments[n-1][0])
+				}
+				if n == ny || n < ny || y.path.remainder {
+					check(method, &bucketsByNumSegments[n-1][1])
+				}
+			}
+		}
+		if x != nil {
+			placeholder := placeholderToken(tr)
+			url, _ := overlapURL(x, y.path, placeholder)
+			err := fmt.Errorf("pattern %s conflicts with pattern %s: both match %s, but neither is more specific", y.path.raw, x.raw, url)
+			return &routeError{int(y.path.index), err}
+		}
+
+		if buckets[y.method] == nil {
+			buckets[y.method] = new([maxSegmentsInPath][2]conflictBucket)
+			methods = append(methods, y.method)
+		}
+		remainder := 0
+		if y.path.remainder {
+			remainder = 1
+		}
+		buckets[y.method][ny-1][remainder].add(y.path)
+	}
+	return nil
+}
+
+// A conflictBucket holds the paths with the same method, the same number
+// of segments and the same remainder flag, checked by checkConflicts.
+type conflictBucket struct {
+	byFirstToken map[string][]*path // the paths starting with fixed tokens
+	wildcards    []*path            // the paths starting with wildcards
+}
+
+// firstToken returns the first token of p, and false if it is a wildcard.
+func firstToken(p *path) (string, bool) {
+	if seg := p.segments[0]; !seg.wildcard() {
+		return seg.token, true
+	}
+	return "", false
+}
+
+func (b *conflictBucket) add(p *path) {
+	token, ok := firstToken(p)
+	if !ok {
+		b.wildcards = append(b.wildcards, p)
+		return
+	}
+	if b.byFirstToken == nil {
+		b.byFirstToken = make(map[string][]*path)
+	}
+	b.byFirstToken[token] = append(b.byFirstToken[token], p)
+}
+
+// each calls f with the paths in b which may match some same requests as
+// p by their first tokens. A fixed first token only matches the same token
+// or a wildcard.
+func (b *conflictBucket) each(p *path, f func(*path)) {
+	for _, q := range b.wildcards {
+		f(q)
+	}
+	if token, ok := firstToken(p); ok {
+		for _, q := range b.byFirstToken[token] {
+			f(q)
+		}
+		return
+	}
+	for _, paths := range b.byFirstToken {
+		for _, q := range paths {
+			f(q)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package tinyrouter
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestServeMuxSyntax(t *testing.T) {
+	var matched string
+	var params Params
+	route := func(method, pattern string) Route {
+		return Route{
+			Method:  method,
+			Pattern: pattern,
+			HandleFunc: func(w http.ResponseWriter, r *http.Request) {
+				matched, params = pattern, PathParams(r)
+			},
+		}
+	}
+	router := New(Config{
+		ServeMuxSyntax: true,
+		Routes: []Route{
+			route("", "GET /items/{id}"),
+			route("", "GET /items/{id}/:edit"),
+			route("POST", "/items/{id}"),
+			route("", "/files/{path...}"),
+			route("", "GET /{$}"),
+			route("", "GET /static/"),
+		},
+	})
+
+	var requestCases = []struct {
+		method  string
+		urlPath string
+		pattern string
+		params  map[string]string
+	}{
+		{"GET", "/items/42", "GET /items/{id}", map[string]string{"id": "42"}},
+		{"HEAD", "/items/42", "GET /items/{id}", map[string]string{"id": "42"}},
+		{"HEAD", "/files/a", "/files/{path...}", map[string]string{"path": "a"}},
+		{"POST", "/items/42", "/items/{id}", map[string]string{"id": "42"}},
+		{"GET", "/items/42/:edit", "GET /items/{id}/:edit", map[string]string{"id": "42"}},
+		{"GET", "/items/42/edit", "", nil},
+		{"DELETE", "/items/42", "", nil},
+		{"GET", "/", "GET /{$}", map[string]string{}},
+		{"GET", "/static/", "GET /static/", map[string]string{}},
+		{"GET", "/static/css/site.css", "GET /static/", map[string]string{}},
+		{"PUT", "/files/a/b/c", "/files/{path...}", map[string]string{"path": "a/b/c"}},
+		{"GET", "/files/", "/files/{path...}", map[string]string{"path": ""}},
+		{"GET", "/files", "", nil},
+		{"GET", "/index.html", "", nil},
+	}
+	for _, rc := range requestCases {
+		matched, params = "", Params{}
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(rc.method, rc.urlPath, nil))
+		if matched != rc.pattern {
+			t.Errorf("%s %s: matched pattern %q, want %q", rc.method, rc.urlPath, matched, rc.pattern)
+			continue
+		}
+		kvs, _ := params.ToMapAndSlice()
+		if len(kvs) != len(rc.params) {
+			t.Errorf("%s %s: params = %v, want %v", rc.method, rc.urlPath, kvs, rc.params)
+			continue
+		}
+		for k, v := range rc.params {
+			if kvs[k] != v {
+				t.Errorf("%s %s: params = %v, want %v", rc.method, rc.urlPath, kvs, rc.params)
+				break
+			}
+		}
+	}
+
+	for _, r := range []Route{
+		route("GET", "POST /items"),
+		route("", "example.com/items"),
+		route("", "/items/{id}x"),
+		route("", "/items/{$}/x"),
+		route("", "/items/{path...}/x"),
+		route("", "/items/{}"),
+		route("", "/items/{1d}"),
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("New should panic for pattern %s", r.Pattern)
+				}
+			}()
+			New(Config{ServeMuxSyntax: true, Routes: []Route{r}})
+		}()
+	}
+}
+
+// The routes are selected like http.ServeMux does.
+func TestServeMuxPrecedence(t *testing.T) {
+	patterns := []string{
+		"GET /a/{x}",
+		"GET /a/b",
+		"HEAD /a/b",
+		"GET /a/{x}/c",
+		"POST /{y}/b",
+		"POST /{y}/{z}",
+		"GET /c/",
+		"GET /c/{x}",
+		"GET /c/{x}/{rest...}",
+		"/{y}",
+	}
+	mux := http.NewServeMux()
+	var routes []Route
+	for _, pattern := range patterns {
+		handle := func(http.ResponseWriter, *http.Request) {}
+		mux.HandleFunc(pattern, handle)
+		routes = append(routes, Route{Pattern: pattern, Name: pattern, HandleFunc: handle})
+	}
+	router := New(Config{ServeMuxSyntax: true, Routes: routes})
+
+	urls := []string{"/a/b/c/d"}
+	tokens := []string{"a", "b", "c", "x"}
+	for _, t1 := range tokens {
+		urls = append(urls, "/"+t1)
+		for _, t2 := range tokens {
+			urls = append(urls, "/"+t1+"/"+t2)
+			for _, t3 := range tokens {
+				urls = append(urls, "/"+t1+"/"+t2+"/"+t3)
+			}
+		}
+	}
+	for _, url := range urls {
+		for _, method := range []string{"GET", "HEAD", "POST", "PUT"} {
+			req := httptest.NewRequest(method, url, nil)
+			h, want := mux.Handler(req)
+			w := httptest.NewRecorder()
+			if h.ServeHTTP(w, req); w.Code == http.StatusMovedPermanently {
+				continue // the path is redirected by http.ServeMux
+			}
+			if p, _ := router.Lookup(method, url); p.RouteName() != want {
+				t.Errorf("%s %s matches %q, want %q", method, url, p.RouteName(), want)
+			}
+		}
+	}
+}
+
+// New panics for the conflicting patterns, like http.ServeMux does.
+func TestServeMuxConflicts(t *testing.T) {
+	for _, test := range []struct {
+		x, y     string
+		conflict bool
+	}{
+		{"/a/{x}", "/{y}/b", true},
+		{"GET /a/{x}", "/a/b", true},
+		{"HEAD /{x}", "GET /a", true},
+		{"/files/{path...}", "/{x}/{y}", true},
+		{"/a/{x}", "/a/{y}", true},
+		{"/a/{x}", "/{y}/b/c", false},
+		{"GET /a/{x}", "POST /{y}/b", false},
+		{"/a/{x}", "GET /a/b", false},
+		{"GET /{x}", "HEAD /a", false},
+		{"/files/{path...}", "/files/{x}/{y}", false},
+		{"/{x}/{$}", "/{x}/{y...}", false},
+		{"GET /users/{$}", "POST /users/{id}", false},
+		{"/{$}", "HEAD /{x}", false},
+		{"/a/{$}", "/{x}/{$}", false},
+		{"/{x}/{$}", "/a/{y}", false},
+	} {
+		handle := func(http.ResponseWriter, *http.Request) {}
+		panics := func(f func()) (panicked bool) {
+			defer func() { panicked = recover() != nil }()
+			f()
+			return false
+		}
+		muxConflict := panics(func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc(test.x, handle)
+			mux.HandleFunc(test.y, handle)
+		})
+		conflict := panics(func() {
+			New(Config{ServeMuxSyntax: true, Routes: []Route{
+				{Pattern: test.x, HandleFunc: handle},
+				{Pattern: test.y, HandleFunc: handle},
+			}})
+		})
+		if muxConflict != test.conflict {
+			t.Errorf("%s and %s: http.ServeMux panics: %v", test.x, test.y, muxConflict)
+		}
+		if conflict != test.conflict {
+			t.Errorf("%s and %s: New panics: %v, want %v", test.x, test.y, conflict, test.conflict)
+		}
+	}
+}
+
+// New accepts the same random route tables as http.ServeMux does.
+func TestServeMuxConflictsRandomTables(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomPattern := func() string {
+		var b strings.Builder
+		b.WriteString([]string{"", "GET ", "HEAD ", "POST "}[rng.Intn(4)])
+		n := 1 + rng.Intn(3)
+		for i := range n {
+			b.WriteByte('/')
+			switch k := rng.Intn(10); {
+			case i == n-1 && k == 0:
+				b.WriteString("{$}")
+			case i == n-1 && k == 1:
+				fmt.Fprintf(&b, "{w%d...}", i)
+			case i == n-1 && k == 2: // ending with a slash
+			case k < 6:
+				b.WriteString([]string{"a", "b"}[k%2])
+			default:
+				fmt.Fprintf(&b, "{w%d}", i)
+			}
+		}
+		return b.String()
+	}
+	panics := func(f func()) (panicked bool) {
+		defer func() { panicked = recover() != nil }()
+		f()
+		return false
+	}
+
+	handle := func(http.ResponseWriter, *http.Request) {}
+	for range 20000 {
+		var patterns []string
+		for range 2 + rng.Intn(5) {
+			patterns = append(patterns, randomPattern())
+		}
+		muxPanics := panics(func() {
+			mux := http.NewServeMux()
+			for _, pattern := range patterns {
+				mux.HandleFunc(pattern, handle)
+			}
+		})
+		var routes []Route
+		for _, pattern := range patterns {
+			routes = append(routes, Route{Pattern: pattern, HandleFunc: handle})
+		}
+		if newPanics := panics(func() { New(Config{ServeMuxSyntax: true, Routes: routes}) }); newPanics != muxPanics {
+			t.Fatalf("New panics: %v, but http.ServeMux panics: %v, for\n\t%s", newPanics, muxPanics, strings.Join(patterns, "\n\t"))
+		}
+	}
+}
+
+func BenchmarkNewServeMux(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		var routes []Route
+		for i := range n {
+			routes = append(routes, Route{
+				Pattern:    fmt.Sprintf("%s /r%d/{id}/items/{item}", []string{"GET", "POST", "PUT"}[i%3], i/3),
+				HandleFunc: func(http.ResponseWriter, *http.Request) {},
+			})
+		}
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for range b.N {
+				New(Config{ServeMuxSyntax: true, Routes: routes})
+			}
+		})
+	}
+}
+
+// In the default syntax, the last parameter of the longest patterns
+// matches the rest of longer request paths. In the syntax of
+// http.ServeMux, only remainder wildcards do.
+func TestTooManyTokens(t *testing.T) {
+	for _, test := range []struct {
+		serveMux bool
+		pattern  string
+		want     []string
+	}{
+		{false, "/:a/:b", []string{"x", "y/z"}},
+		{true, "/{a}/{b}", nil},
+		{true, "/{a}/{b...}", []string{"x", "y/z"}},
+	} {
+		router := New(Config{ServeMuxSyntax: test.serveMux, Routes: []Route{{
+			Method:     "GET",
+			Pattern:    test.pattern,
+			HandleFunc: func(http.ResponseWriter, *http.Request) {},
+		}}})
+		p, _ := router.Lookup("GET", "/x/y/z")
+		if _, values := p.ToMapAndSlice(); !slices.Equal(values, test.want) {
+			t.Errorf("/x/y/z matches %s with %q, want %q", test.pattern, values, test.want)
+		}
+	}
+}
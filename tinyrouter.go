@@ -1,11 +1,14 @@
 // Tinyrouter is Go http router supporting custom parameters in paths.
-// The implementation contains only 500 lines of code.
 package tinyrouter
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"iter"
+	"math"
 	"net/http"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -13,28 +16,62 @@ import (
 
 // A Params encapsulates the parameters in request URL path.
 type Params struct {
-	path   *path
-	tokens []string
+	path *path
+
+	// The request path (without the leading slash) matched by path.
+	// The parameter values are sliced from it on demand.
+	urlPath string
+
+	// Token i of urlPath (except the last one of path) ends at offset
+	// ends[i]. Not recorded if urlPath is longer than math.MaxUint16.
+	ends [maxSegmentsInPath - 1]uint16
+}
+
+// makeParams returns the Params value for path matching tokens.
+func makeParams(path *path, tokens *pathTokens) Params {
+	p := Params{path: path, urlPath: tokens.path}
+	if len(p.urlPath) <= math.MaxUint16 {
+		for i := range len(path.segments) - 1 {
+			p.ends[i] = uint16(tokens.ends[i])
+		}
+	}
+	return p
+}
+
+// paramsOf returns the Params value for path matching urlPath.
+func paramsOf(path *path, urlPath string) Params {
+	var tokens pathTokens
+	tokens.tokenize(urlPath, len(path.segments))
+	return makeParams(path, &tokens)
 }
 
 // Value returns the parameter value corresponds to key.
 // This method will never panic.
 func (p Params) Value(key string) string {
+	v, _ := p.Lookup(key)
+	return v
+}
+
+// Lookup returns the parameter value corresponds to key.
+// The second result is false if the matched route has no such parameter,
+// or the parameter is an omitted optional one without a default value.
+// This method will never panic.
+func (p Params) Lookup(key string) (string, bool) {
 	if p.path != nil {
 		for _, seg := range p.path.wildcards {
 			if seg.token == key {
-				return p.tokens[seg.colIndex]
+				return p.value(seg), true
 			}
 		}
 	}
-	return ""
+	return "", false
 }
 
 // ValueByIndex returns the parameter value corresponds to index i.
 // This method will never panic.
 func (p Params) ValueByIndex(i int) string {
 	if p.path != nil && i >= 0 && i < len(p.path.wildcards) {
-		return p.tokens[p.path.wildcards[i].colIndex]
+		return p.value(p.path.wildcards[i])
 	}
 	return ""
 }
@@ -45,16 +82,116 @@ func (p Params) ToMapAndSlice() (kvs map[string]string, vs []string) {
 	if p.path != nil {
 		kvs = make(map[string]string, p.path.numParams)
 		vs = make([]string, 0, p.path.numParams)
-		for _, seg := range p.path.segments {
-			if seg.wildcard() {
-				vs = append(vs, p.tokens[seg.colIndex])
-				kvs[seg.token] = p.tokens[seg.colIndex]
-			}
+		for _, seg := range p.path.wildcards {
+			vs = append(vs, p.value(seg))
+			kvs[seg.token] = p.value(seg)
 		}
 	}
 	return
 }
 
+// Pattern returns the pattern of the matched route.
+func (p Params) Pattern() string {
+	if p.path == nil {
+		return ""
+	}
+	return p.path.raw
+}
+
+// RouteName returns the name of the matched route. See Route.Name.
+func (p Params) RouteName() string {
+	if p.path == nil {
+		return ""
+	}
+	return p.path.name
+}
+
+// RouteMetadata returns the value of key in the metadata
+// of the matched route. See Route.Metadata.
+func (p Params) RouteMetadata(key string) string {
+	if p.path == nil {
+		return ""
+	}
+	return p.path.metadata[key]
+}
+
+// RouteIndex returns the index of the matched route in Config.Routes,
+// or -1 if p is not passed by a TinyRouter, such as the ones returned
+// by NewParams and the ones passed by the routers made by Generate.
+func (p Params) RouteIndex() int {
+	if p.path == nil {
+		return -1
+	}
+	return int(p.path.index)
+}
+
+// Len returns the number of parameters.
+// Omitted optional parameters without default values are not counted.
+func (p Params) Len() int {
+	if p.path == nil {
+		return 0
+	}
+	return len(p.path.wildcards)
+}
+
+// Names returns the names of the parameters, in the order
+// they appear in the pattern of the matched route.
+func (p Params) Names() []string {
+	if p.path == nil {
+		return nil
+	}
+	names := make([]string, len(p.path.wildcards))
+	for i, seg := range p.path.wildcards {
+		names[i] = seg.token
+	}
+	return names
+}
+
+// All returns an iterator over the names and values of the parameters,
+// in the order they appear in the pattern of the matched route.
+func (p Params) All() iter.Seq2[string, string] {
+	return func(yield func(name, value string) bool) {
+		if p.path == nil {
+			return
+		}
+		for _, seg := range p.path.wildcards {
+			if !yield(seg.token, p.value(seg)) {
+				return
+			}
+		}
+	}
+}
+
+func (p Params) value(seg *segment) string {
+	if seg.colIndex < 0 { // an omitted optional parameter
+		return seg.defaultValue
+	}
+	if len(p.urlPath) > math.MaxUint16 {
+		return p.scanValue(seg)
+	}
+	start := 0
+	if seg.colIndex > 0 {
+		start = int(p.ends[seg.colIndex-1]) + 1
+	}
+	if int(seg.colIndex) == len(p.path.segments)-1 {
+		return p.urlPath[start:] // the last token, or the remaining path for a remainder wildcard
+	}
+	return p.urlPath[start:p.ends[seg.colIndex]]
+}
+
+// scanValue is like value, but it finds the token by scanning urlPath,
+// for the paths too long to record the token offsets.
+func (p Params) scanValue(seg *segment) string {
+	s := p.urlPath
+	for i := int32(0); i < seg.colIndex; i++ {
+		s = s[strings.IndexByte(s, '/')+1:]
+	}
+	if i := strings.IndexByte(s, '/'); i >= 0 && int(seg.colIndex) < len(p.path.segments)-1 {
+		return s[:i]
+	}
+	return s
+}
+
 // To avoid being overwritten by outer code.
 type paramsKeyType struct{}
 
@@ -64,6 +201,61 @@ func PathParams(req *http.Request) Params {
 	return p
 }
 
+// ContextWithParams returns a copy of ctx carrying p, which will be
+// returned by PathParams for the requests using the returned context.
+// It is mainly used to test handlers without routers.
+func ContextWithParams(ctx context.Context, p Params) context.Context {
+	return context.WithValue(ctx, paramsKeyType{}, p)
+}
+
+// NewParams returns the Params value which a router would pass to the
+// handler of pattern, with the parameters being values (in order).
+// Trailing optional parameters may be omitted in values.
+// NewParams is mainly used to test handlers without routers.
+func NewParams(pattern string, values ...string) (Params, error) {
+	return newParams(pattern, false, values)
+}
+
+// NewServeMuxParams is the same as NewParams, except that pattern is
+// in the syntax of http.ServeMux. See Config.ServeMuxSyntax.
+func NewServeMuxParams(pattern string, values ...string) (Params, error) {
+	return newParams(pattern, true, values)
+}
+
+func newParams(pattern string, serveMux bool, values []string) (Params, error) {
+	paths, err := parsePattern(&Route{Pattern: pattern}, serveMux)
+	if err != nil {
+		return Params{}, err
+	}
+
+	for _, path := range paths {
+		var present []*segment
+		for _, seg := range path.wildcards {
+			if seg.colIndex >= 0 {
+				present = append(present, seg)
+			}
+		}
+		if len(present) != len(values) {
+			continue
+		}
+
+		tokens := make([]string, len(path.segments))
+		for i, seg := range path.segments {
+			if !seg.wildcard() {
+				tokens[i] = seg.token
+			}
+		}
+		for i, seg := range present {
+			if strings.Contains(values[i], "/") && !(path.remainder && int(seg.colIndex) == len(tokens)-1) {
+				return Params{}, errors.New("value of parameter [" + seg.token + "] contains a slash: " + values[i])
+			}
+			tokens[seg.colIndex] = values[i]
+		}
+		return paramsOf(path, strings.Join(tokens, "/")), nil
+	}
+	return Params{}, fmt.Errorf("%d values are given for pattern %s", len(values), pattern)
+}
+
 type segment struct {
 	// Which path this segment belongs to.
 	path *path
@@ -72,53 +264,159 @@ type segment struct {
 	// For wildcard segment, this is the parameter name.
 	token string
 
-	// The next segment in path and the same0column segment in the next paths.
-	nextInRow, nextInCol *segment
+	// The column of this segment in its path.
+	// It is -1 for an omitted optional parameter.
+	colIndex int32
+
+	// Whether or not this segment is a parameter.
+	isWildcard bool
+
+	// For an omitted optional parameter (colIndex is -1),
+	// this is the value reported in Params.
+	defaultValue string
+
+	// Whether or not the value of this wildcard segment is redacted
+	// in logs and JSON. See Config.SensitiveParams.
+	sensitive bool
+}
+
+func (seg *segment) wildcard() bool {
+	return seg.isWildcard
+}
+
+// A segmentTable is the compiled form of a path group (the sorted paths
+// with the same method and number of segments), which is used in serving.
+// The segments are stored column by column in a contiguous array and refer
+// to each other by indexes, so that scanning a column touches adjacent
+// memory only. The segment at row r and column c is at index c*numRows+r,
+// so the next segment in the same row is numRows elements after it.
+type segmentTable struct {
+	paths    []*path // one per row
+	numRows  int32
+	segments []tableSegment
+
+	// The index of the first segment in the last column.
+	// The segment at index i >= lastColumn is in paths[i-lastColumn].
+	lastColumn int32
+}
+
+type tableSegment struct {
+	// The same as segment.token.
+	token string
 
 	// The first segment (at the same column) with a larger token, but
-	// with the same length. A non-nil startLarger can't be wildcard.
-	startLarger *segment
+	// with the same length. A startLarger can't be wildcard.
+	startLarger int32
 
 	// The first segment (at the same column) with a longer token.
 	// A startLonger may be equal to startWildcard.
-	startLonger *segment
-
-	// The first wildcard segment (at the same column).
-	// If seg.startWildcard == seg, then segment seg is wildcard.
-	startWildcard *segment
+	startLonger int32
 
-	// rowIndex is for debug only.
-	rowIndex, colIndex int32
+	// The first wildcard segment (at the same column). If the
+	// startWildcard of a segment is its own index, it is wildcard.
+	startWildcard int32
 
 	// How many equal prefix bytes with startLarger.
 	numSameBytes int32
+
+	// If it is not zero, the segment is the first one in a segment
+	// group with many fixed segments, which end at index wideEnd
+	// (exclusive). Such fixed segments are binary searched.
+	wideEnd int32
 }
 
-func (seg *segment) wildcard() bool {
-	return seg.startWildcard == seg
+// The index of no segments, for the relations not existing.
+const noSegment = -1
+
+// The fixed segments in a segment group with at least so many
+// distinct tokens are binary searched instead of being scanned.
+const minWideGroupTokens = 16
+
+func newSegmentTable(paths []*path) *segmentTable {
+	numRows, numCols := len(paths), len(paths[0].segments)
+	t := &segmentTable{
+		paths:    paths,
+		numRows:  int32(numRows),
+		segments: make([]tableSegment, numRows*numCols),
+	}
+	t.lastColumn = int32(len(t.segments) - numRows)
+	for col := 0; col < numCols; col++ {
+		for row, path := range paths {
+			i := int32(col*numRows + row)
+			seg := path.segments[col]
+			t.segments[i] = tableSegment{
+				token:         seg.token,
+				startLarger:   noSegment,
+				startLonger:   noSegment,
+				startWildcard: noSegment,
+			}
+			if seg.wildcard() {
+				t.segments[i].startWildcard = i
+			}
+		}
+	}
+
+	t.buildRelations(0, t.numRows)
+
+	statSamePrefixBytes := func(a, b string, num *int32) {
+		for ; *num < int32(len(a)) && a[*num] == b[*num]; *num++ {
+		}
+	}
+	for i := range t.segments {
+		if seg := &t.segments[i]; seg.startLarger != noSegment {
+			statSamePrefixBytes(seg.token, t.segments[seg.startLarger].token, &seg.numSameBytes)
+		}
+	}
+	return t
 }
 
-func (seg *segment) next() *segment {
-	if seg == nil {
-		return nil
+func (t *segmentTable) wildcard(i int32) bool {
+	return t.segments[i].startWildcard == i
+}
+
+// eachGroup calls f for the subgroups of the segment group from index
+// start to index end (exclusive), with each subgroup being from index
+// first to index next (exclusive): the runs of fixed segments sharing
+// the same tokens in order, then the wildcard segments, if any.
+func (t *segmentTable) eachGroup(start, end int32, f func(first, next int32, wildcard bool)) {
+	i := start
+	for i != end && !t.wildcard(i) {
+		first := i
+		for i++; i != end && !t.wildcard(i) && t.segments[i].token == t.segments[first].token; i++ {
+		}
+		f(first, i, false)
 	}
-	return seg.nextInRow
+	if i != end {
+		f(i, end, true)
+	}
+}
+
+// segment returns the segment at index i.
+func (t *segmentTable) segment(i int32) *segment {
+	return t.paths[i%t.numRows].segments[i/t.numRows]
 }
 
-func (seg *segment) row() int {
-	if seg == nil {
+// row returns the row of the segment at index i,
+// or -1 if i is noSegment. It is for debug only.
+func (t *segmentTable) row(i int32) int {
+	if i == noSegment {
 		return -1
 	}
-	return int(seg.rowIndex)
+	return int(i % t.numRows)
 }
 
 type path struct {
-	raw       string     // unparsed pattern
-	segments  []*segment // []segment is better? Need benchmark. (or [][]segments for a path group?)
-	wildcards []*segment // for fast parameter value look-up
-	handle    func(http.ResponseWriter, *http.Request)
-	numParams int32 // how many wildcard segments in this path
-	row       int32 // row index in a path group
+	raw          string            // unparsed pattern
+	name         string            // Route.Name
+	metadata     map[string]string // Route.Metadata
+	segments     []*segment        // lookups use the segmentTable of the path group instead
+	wildcards    []*segment        // for fast parameter value look-up, including omitted ones with defaults
+	handle       func(http.ResponseWriter, *http.Request)
+	handleParams func(http.ResponseWriter, *http.Request, Params) // Route.Handle
+	numParams    int32                                            // how many parameters in this path
+	row          int32                                            // row index in a path group
+	index        int32                                            // index of the route in Config.Routes
+	remainder    bool                                             // whether or not the last segment matches the remaining path
 }
 
 func compareSegments(sa, sb *segment) int {
@@ -155,136 +453,240 @@ func comparePaths(x, y *path) int {
 	return 0
 }
 
-func parsePath(r Route) *path {
+// parsePattern parses the pattern of r in the syntax of http.ServeMux
+// if serveMux is true, or else in the default syntax.
+func parsePattern(r *Route, serveMux bool) ([]*path, error) {
+	if serveMux {
+		return parseServeMuxPattern(r)
+	}
+	return parsePaths(*r)
+}
+
+// parsePaths parses the pattern of r. A pattern ending with n optional
+// parameters is expanded into n+1 paths, from the longest to the shortest.
+func parsePaths(r Route) ([]*path, error) {
 	if len(r.Pattern) == 0 || r.Pattern[0] != '/' {
-		panic("a pattern shell start with a slash: " + r.Pattern)
+		return nil, errors.New("a pattern shell start with a slash: " + r.Pattern)
 	}
 
-	path := &path{raw: r.Pattern, handle: r.HandleFunc}
+	return parseTokens(r, strings.Split(r.Pattern[1:], "/"))
+}
+
+// parseTokens is the same as parsePaths, except the pattern
+// of r has been split into tokens (in the default syntax).
+func parseTokens(r Route, tokens []string) ([]*path, error) {
+	if len(tokens) > maxSegmentsInPath {
+		return nil, errors.New("too many segments in path: " + r.Pattern)
+	}
+
+	numRequired := len(tokens)
+	names := make(map[string]bool, len(tokens))
+	for i, token := range tokens {
+		if !strings.HasPrefix(token, ":") {
+			if numRequired < len(tokens) {
+				return nil, errors.New("only trailing parameters can be optional: " + r.Pattern)
+			}
+			continue
+		}
+		name, optional, _, hasDefault := parseParam(token)
+		if optional && !hasDefault && !strings.HasSuffix(token, "?") {
+			return nil, errors.New("bad optional parameter [" + token + "] in " + r.Pattern)
+		}
+		if names[name] {
+			return nil, errors.New("duplicated parameter name [" + name + "] in " + r.Pattern)
+		}
+		names[name] = true
+		if optional {
+			if numRequired == len(tokens) {
+				numRequired = i
+			}
+		} else if numRequired < len(tokens) {
+			return nil, errors.New("only trailing parameters can be optional: " + r.Pattern)
+		}
+	}
+
+	paths := make([]*path, 0, len(tokens)-numRequired+1)
+	for n := len(tokens); n >= numRequired; n-- {
+		paths = append(paths, parsePath(r, tokens, n))
+	}
+	return paths, nil
+}
+
+// parseParam parses a parameter token, which is in the form of
+// ":name", ":name?" or ":name?=default". The optional result is
+// true but hasDefault is false for malformed tokens like ":name?x".
+func parseParam(token string) (name string, optional bool, defaultValue string, hasDefault bool) {
+	name = token[1:]
+	i := strings.IndexByte(name, '?')
+	if i < 0 {
+		return name, false, "", false
+	}
+	name, defaultValue = name[:i], name[i+1:]
+	if defaultValue == "" {
+		return name, true, "", false
+	}
+	if defaultValue[0] != '=' {
+		return name, true, "", false
+	}
+	return name, true, defaultValue[1:], true
+}
+
+// parsePath builds a path with the first n tokens. The parameters in
+// the remaining tokens are omitted optional ones.
+func parsePath(r Route, tokens []string, n int) *path {
+	path := &path{raw: r.Pattern, name: r.Name, metadata: r.Metadata, handle: r.HandleFunc, handleParams: r.Handle, index: -1}
 
 	buildSegment := func(pattern string, segs []*segment) (seg *segment) {
 		if strings.HasPrefix(pattern, ":") {
-			for _, seg := range segs {
-				if seg.wildcard() && seg.token == pattern[1:] {
-					panic("duplicated parameter name [" + pattern[1:] + "] in " + r.Pattern)
-				}
-			}
-			seg = &segment{path: path, token: pattern[1:]}
-			seg.startWildcard = seg
+			name, _, _, _ := parseParam(pattern)
+			seg = &segment{path: path, token: name, isWildcard: true}
 			path.numParams++
 			path.wildcards = append(path.wildcards, seg)
 		} else {
-			seg = &segment{path: path, token: pattern}
+			// A leading backslash escapes the token, so that
+			// a fixed token may also start with a colon.
+			seg = &segment{path: path, token: strings.TrimPrefix(pattern, "\\")}
 		}
 
-		if seg.colIndex = int32(len(segs)); seg.colIndex > 0 {
-			segs[seg.colIndex-1].nextInRow = seg
-		}
+		seg.colIndex = int32(len(segs))
 		return
 	}
 
-	var segs []*segment
-	for pattern := r.Pattern[1:]; ; {
-		i := strings.IndexRune(pattern, '/')
-		if i >= 0 {
-			segs = append(segs, buildSegment(pattern[:i], segs))
-			pattern = pattern[i+1:]
-		} else {
-			segs = append(segs, buildSegment(pattern, segs))
-			break
-		}
+	present := tokens[:n]
+	if n == 0 {
+		present = []string{""} // all parameters are omitted, so the path is "/"
 	}
-	if len(segs) > maxSegmentsInPath {
-		panic("too many segments in path: " + r.Pattern)
+	segs := make([]*segment, 0, len(present))
+	for _, pattern := range present {
+		segs = append(segs, buildSegment(pattern, segs))
 	}
 	path.segments = segs
 
+	for _, pattern := range tokens[n:] {
+		name, _, defaultValue, hasDefault := parseParam(pattern)
+		if hasDefault {
+			seg := &segment{path: path, token: name, colIndex: -1, isWildcard: true, defaultValue: defaultValue}
+			path.numParams++
+			path.wildcards = append(path.wildcards, seg)
+		}
+	}
+
 	return path
 }
 
-func buildSegmentRelations(startSeg, endSeg *segment) {
-	if startSeg == nil {
+// buildRelations builds the relations between the segments in
+// the segment group from index start to index end (exclusive),
+// which share the same previous segments in their rows, and in
+// the groups following it.
+func (t *segmentTable) buildRelations(start, end int32) {
+	if start >= int32(len(t.segments)) {
 		return
 	}
 
-	seg, lastSeg, shortStart, smallerStart := startSeg, startSeg, startSeg, startSeg
+	segs, n := t.segments, t.numRows
+	seg, lastSeg, shortStart, smallerStart := start, start, start, start
+	numTokens := 1 // distinct fixed tokens, if any
 
 	updateStartLargers := func() {
-		if seg == nil || seg.wildcard() || len(lastSeg.token) != len(seg.token) {
+		if seg == end || t.wildcard(seg) || len(segs[lastSeg].token) != len(segs[seg].token) {
 			return
 		}
-		for smaller := smallerStart; smaller != seg; smaller = smaller.nextInCol {
-			if smaller.wildcard() {
+		for smaller := smallerStart; smaller != seg; smaller++ {
+			if t.wildcard(smaller) {
 				panic("smaller is wildcard")
 			}
-			if lastSeg.wildcard() {
+			if t.wildcard(lastSeg) {
 				panic("lastSeg is wildcard")
 			}
-			smaller.startLarger = seg
+			segs[smaller].startLarger = seg
 		}
 	}
 
 	updateStartLongers := func() {
-		for short := shortStart; short != seg; short = short.nextInCol {
-			//if short.wildcard() {panic("short is wildcard")}
-			short.startLonger = seg
+		for short := shortStart; short != seg; short++ {
+			segs[short].startLonger = seg
 		}
 	}
 
 	updateStartWildcards := func() {
-		for fixed := startSeg; fixed != seg; fixed = fixed.nextInCol {
-			if fixed.wildcard() {
+		for fixed := start; fixed != seg; fixed++ {
+			if t.wildcard(fixed) {
 				panic("fixed is wildcard")
 			}
-			fixed.startWildcard = seg
+			segs[fixed].startWildcard = seg
 		}
 	}
 
-	for ; seg != endSeg; lastSeg, seg = seg, seg.nextInCol {
-		if seg.wildcard() {
+	for ; seg != end; lastSeg, seg = seg, seg+1 {
+		if t.wildcard(seg) {
 			updateStartLongers()
 			updateStartWildcards()
-			buildSegmentRelations(smallerStart.next(), seg.next())
+			t.buildRelations(smallerStart+n, seg+n)
 			break
 		}
 
-		if len(seg.token) > len(shortStart.token) {
+		if len(segs[seg].token) > len(segs[shortStart].token) {
 			updateStartLargers()
 			updateStartLongers()
-			buildSegmentRelations(smallerStart.next(), seg.next())
+			t.buildRelations(smallerStart+n, seg+n)
 			shortStart, smallerStart = seg, seg
+			numTokens++
 			continue
 		}
 
-		if compareSegments(seg, smallerStart) > 0 {
+		if compareSegments(t.segment(seg), t.segment(smallerStart)) > 0 {
 			updateStartLargers()
-			buildSegmentRelations(smallerStart.next(), seg.next())
+			t.buildRelations(smallerStart+n, seg+n)
 			smallerStart = seg
+			numTokens++
 		}
 	}
 
-	// Come here for two reasons: wildcard or endSeg encountered.
-	if seg == endSeg {
-		buildSegmentRelations(smallerStart.next(), endSeg.next())
-		return
+	if numTokens >= minWideGroupTokens {
+		segs[start].wideEnd = seg
 	}
 
-	if seg == nil {
-		panic("seg is nil")
+	// Come here for two reasons: wildcard or end encountered.
+	if seg == end {
+		t.buildRelations(smallerStart+n, end+n)
+		return
 	}
-	if !seg.wildcard() {
+
+	if !t.wildcard(seg) {
 		panic("seg is not wildcard")
 	}
-	buildSegmentRelations(seg.next(), endSeg.next())
+	t.buildRelations(seg+n, end+n)
 }
 
-func findHandlePath(tokens []string, entrySeg *segment) *path {
-	for token, seg := tokens[0], entrySeg; seg != entrySeg.startWildcard; {
+// findHandlePath returns the path matching the tokens from column col
+// in the segment group starting at index entry.
+func (t *segmentTable) findHandlePath(tokens *pathTokens, col int, entry int32, budget *lookupBudget) *path {
+	segs := t.segments
+	startWildcard := segs[entry].startWildcard
+	if segs[entry].wideEnd != 0 {
+		i := t.searchFixedSegment(tokens.token(col), entry, budget)
+		if i == noSegment {
+			goto Wildcard
+		}
+		if i >= t.lastColumn {
+			return t.paths[i-t.lastColumn]
+		}
+		if path := t.findHandlePath(tokens, col+1, i+t.numRows, budget); path != nil || budget.noBacktracking {
+			return path
+		}
+		goto Wildcard
+	}
+
+	for token, i := tokens.token(col), entry; i != startWildcard; {
+		if !budget.spend() {
+			return nil
+		}
+		seg := &segs[i]
 		if len(seg.token) > len(token) {
 			break
 		}
 		if len(seg.token) < len(token) {
-			seg = seg.startLonger
+			i = seg.startLonger
 			continue
 		}
 
@@ -296,22 +698,26 @@ func findHandlePath(tokens []string, entrySeg *segment) *path {
 					goto Wildcard
 				}
 				if seg.token[k] < token[k] {
-					if seg.startLarger == nil || seg.numSameBytes < int32(k) {
+					if seg.startLarger == noSegment || seg.numSameBytes < int32(k) {
 						goto Wildcard
 					}
-					seg = seg.startLarger
+					if !budget.spend() {
+						return nil
+					}
+					i = seg.startLarger
+					seg = &segs[i]
 					goto Next
 				}
 				k++
 			}
 		}
 
-		if seg.nextInRow == nil {
-			return seg.path
+		if i >= t.lastColumn {
+			return t.paths[i-t.lastColumn]
 		}
 
-		path := findHandlePath(tokens[1:], seg.nextInRow)
-		if path != nil {
+		path := t.findHandlePath(tokens, col+1, i+t.numRows, budget)
+		if path != nil || budget.noBacktracking {
 			return path
 		}
 
@@ -319,16 +725,58 @@ func findHandlePath(tokens []string, entrySeg *segment) *path {
 	}
 
 Wildcard:
-	entrySeg = entrySeg.startWildcard
-	if entrySeg == nil {
+	if !budget.spend() {
 		return nil
 	}
+	if startWildcard == noSegment {
+		return nil
+	}
+
+	if startWildcard >= t.lastColumn {
+		return t.paths[startWildcard-t.lastColumn]
+	}
+
+	return t.findHandlePath(tokens, col+1, startWildcard+t.numRows, budget)
+}
 
-	if entrySeg.nextInRow == nil {
-		return entrySeg.path
+// searchFixedSegment binary searches the first fixed segment matching
+// token in the wide segment group starting at index entry. Like the scan
+// in findHandlePath, each step compares token with a segment.
+func (t *segmentTable) searchFixedSegment(token string, entry int32, budget *lookupBudget) int32 {
+	segs := t.segments
+	lo, hi := entry, segs[entry].wideEnd
+	end := hi
+	for lo < hi {
+		if !budget.spend() {
+			return noSegment
+		}
+		mid := int32(uint32(lo+hi) >> 1)
+		if s := segs[mid].token; len(s) < len(token) || len(s) == len(token) && s < token {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if lo < end && segs[lo].token == token {
+		return lo
 	}
+	return noSegment
+}
+
+// A lookupBudget limits the work of looking up the path for a request.
+type lookupBudget struct {
+	steps int // how many steps are left
+
+	// Don't fall back to the wildcard segment in a column
+	// after a fixed segment in the column matches a token.
+	noBacktracking bool
+}
 
-	return findHandlePath(tokens[1:], entrySeg.nextInRow)
+// spend consumes a step. It reports whether or not
+// the budget is still sufficient.
+func (budget *lookupBudget) spend() bool {
+	budget.steps--
+	return budget.steps >= 0
 }
 
 // Hard limit for maximum number of segments in path.
@@ -339,14 +787,110 @@ type TinyRouter struct {
 	// Used in initialization phase and in dumping.
 	pathsByMethod map[string]*[maxSegmentsInPath][]*path
 
-	// Used in serving phase. The entry segment is paths[0].segments[0].
-	entryByMethod map[string]*[maxSegmentsInPath]*segment
+	// Used in serving phase. The tables are compiled from the above paths.
+	tables methodTables
+
+	// Same as the above two, but for the paths ending with a remainder
+	// wildcard. They are only used when no other paths match.
+	remainderPathsByMethod map[string]*[maxSegmentsInPath][]*path
+	remainderTables        methodTables
 
 	// To avoid power exhausting attacks in request path parsing.
 	maxNumTokens int
 
+	// Whether or not to select routes like http.ServeMux.
+	// See Config.ServeMuxSyntax.
+	serveMuxSyntax bool
+
 	// The default one in the standard http package is used on nil.
 	othersHandleFunc http.HandlerFunc
+
+	// Whether or not to call http.Request.SetPathValue for parameters.
+	setPathValues bool
+
+	// Which path wins if multiple paths match a request path.
+	precedence Precedence
+
+	// See Config.MaxLookupSteps and Config.NoBacktracking.
+	maxLookupSteps int
+	noBacktracking bool
+
+	// Nil if Config.LookupCacheSize is zero.
+	cache *lookupCache
+
+	// Config.Routes, with the methods in ServeMux patterns resolved.
+	// Used in diffing. They only cost the memory of the Route values,
+	// for their patterns, handlers, names and metadata are also
+	// referenced by the paths.
+	routes []Route
+
+	// The names of the handlers of the routes in the route file, if the
+	// router is loaded by LoadRouter, or "" for the routes added by the
+	// configure callback. Used in diffing.
+	handlerNames []string
+}
+
+// methodTables holds the segment tables of the path groups by method.
+// The tables of the standard methods (and the blank one) are found by
+// a switch instead of a map lookup.
+type methodTables struct {
+	standard [numStandardMethods]*[maxSegmentsInPath]*segmentTable
+	custom   map[string]*[maxSegmentsInPath]*segmentTable
+}
+
+const numStandardMethods = 10
+
+// standardMethodIndex returns the index of the tables of method in
+// methodTables.standard, or -1 if method is not a standard one.
+func standardMethodIndex(method string) int {
+	switch method {
+	case "":
+		return 0
+	case http.MethodGet:
+		return 1
+	case http.MethodHead:
+		return 2
+	case http.MethodPost:
+		return 3
+	case http.MethodPut:
+		return 4
+	case http.MethodPatch:
+		return 5
+	case http.MethodDelete:
+		return 6
+	case http.MethodOptions:
+		return 7
+	case http.MethodConnect:
+		return 8
+	case http.MethodTrace:
+		return 9
+	}
+	return -1
+}
+
+// get returns the tables of method, or nil if there are none.
+func (mt *methodTables) get(method string) *[maxSegmentsInPath]*segmentTable {
+	if i := standardMethodIndex(method); i >= 0 {
+		return mt.standard[i]
+	}
+	return mt.custom[method]
+}
+
+// add returns the tables of method, which are created if necessary.
+func (mt *methodTables) add(method string) *[maxSegmentsInPath]*segmentTable {
+	if tables := mt.get(method); tables != nil {
+		return tables
+	}
+	tables := &[maxSegmentsInPath]*segmentTable{}
+	if i := standardMethodIndex(method); i >= 0 {
+		mt.standard[i] = tables
+	} else {
+		if mt.custom == nil {
+			mt.custom = make(map[string]*[maxSegmentsInPath]*segmentTable)
+		}
+		mt.custom[method] = tables
+	}
+	return tables
 }
 
 // A Config value specifies the properties of a TinyRouter.
@@ -358,6 +902,55 @@ type Config struct {
 	// Nil means http.NotFound.
 	OthersHandleFunc http.HandlerFunc
 
+	// Parse the patterns of Routes in the syntax of http.ServeMux
+	// (since Go 1.22), instead of the default one, and select routes
+	// like http.ServeMux does. See Route for details.
+	ServeMuxSyntax bool
+
+	// Also pass parameters through http.Request.SetPathValue, so that
+	// they are available by calling http.Request.PathValue.
+	// This costs more allocations.
+	SetPathValues bool
+
+	// The names of the parameters whose values are redacted
+	// when Params values are logged by log/slog or encoded in JSON.
+	SensitiveParams []string
+
+	// Which route is selected if multiple routes match a request path.
+	// The default is PrecedenceLeftToRight.
+	Precedence Precedence
+
+	// The maximum number of steps spent in looking up the route for
+	// a request. A step is roughly a comparison between a token in the
+	// request path and a segment in a pattern. Requests which exhaust
+	// the budget are handled by OthersHandleFunc. Zero means no limits.
+	MaxLookupSteps int
+
+	// By default, if a fixed segment matches a token in the request path
+	// but no routes are found down that way, the wildcard segment in the
+	// same column is tried. This backtracking may be costly for some
+	// tables mixing fixed and wildcard segments. Setting NoBacktracking
+	// disables it, so that each column of a segment table is scanned at
+	// most once in a lookup, but some requests which would be matched by
+	// wildcard routes will be handled by OthersHandleFunc instead. The
+	// steps spent in a table are then bounded by its number of segments
+	// times one more than the width of its widest column (the fixed
+	// tokens plus the wildcard, see GroupAnalysis.WidestColumn), and the
+	// lookup cost by the sum of them over the tables tried: the one with
+	// as many segments as the request path, the ones with remainder
+	// wildcards, and the ones of the fallback methods in the syntax of
+	// http.ServeMux.
+	// It only works with PrecedenceLeftToRight.
+	NoBacktracking bool
+
+	// The maximum number of the successful lookup results to cache.
+	// Requests with the same method and URL path as a cached one skip
+	// looking up, which benefits tables with deep wildcard fallbacks
+	// under skewed traffic. When the cache is full, a result which is not
+	// used recently is evicted (by an approximation of LRU). Zero means
+	// no caching. See CacheStats.
+	LookupCacheSize int
+
 	// todo:
 	// Ignore tailing slash or not.
 	// Explicit routes have higher priorities.
@@ -366,40 +959,178 @@ type Config struct {
 
 // A Route value specifies a request method, path pattern and
 // the corresponding http handler function.
+//
+// A token starting with a colon in a pattern is a parameter.
+// Trailing parameters may be optional, in the form of ":name?",
+// or ":name?=value" to declare a default value for the omitted case.
+// For example, "/posts/:page?=1" is equivalent to two routes,
+// "/posts/:page" and "/posts" (with page being "1").
+//
+// A leading backslash in a token is removed and makes the rest of the
+// token literal. For example, `/v1/things/\:batchGet` matches the
+// request path "/v1/things/:batchGet" only, and `\*` matches "*".
+//
+// The route for a request must have the same method as the request.
+// The last parameter of the longest patterns also matches the rest of
+// the request paths with more tokens, so "/x/y/z" matches "/:a/:b" with
+// b being "y/z" if no patterns have more than two tokens.
+//
+// If Config.ServeMuxSyntax is set, patterns are written in the syntax
+// of http.ServeMux instead, such as "GET /items/{id}", "/files/{path...}"
+// and "/{$}", and routes are selected like http.ServeMux does:
+//
+//   - The method in a pattern is used if Method is blank. A route with
+//     a blank method matches requests with any method, and a GET route
+//     also matches HEAD requests, but only when no routes with the exact
+//     request method match the request path.
+//   - A pattern ending with a slash matches all paths with the pattern
+//     as prefix, unless it ends with "{$}". Such paths only match the
+//     requests which no other paths match. Request paths with more
+//     tokens than the other patterns don't match them.
+//   - The most specific route matching a request is selected, if the
+//     Precedence is PrecedenceLeftToRight and NoBacktracking is not set.
+//     Like http.ServeMux panics, New panics if some requests are matched
+//     by two routes, but neither of them is more specific than the other,
+//     such as "/a/{x}" and "/{y}/b", or "GET /a/{x}" and "/a/b".
+//
+// Some requests are routed differently from http.ServeMux though. Host
+// patterns are not supported. A wildcard not ending with "..." also matches
+// an empty token, such as the last one of "/items/". It doesn't conflict
+// with "{$}" though, so "POST /items/" is routed to "POST /items/{id}"
+// rather than "/items/{$}". Request paths are matched after being
+// unescaped, and they are neither cleaned nor redirected (such as from
+// "/static" to "/static/" for "/static/").
 type Route struct {
 	Method, Pattern string
 	HandleFunc      http.HandlerFunc
+
+	// Handle is an alternative of HandleFunc. It receives parameters
+	// directly instead of through the request context, so that no
+	// allocations are needed to pass parameters.
+	// Only one of HandleFunc and Handle may be set.
+	Handle func(http.ResponseWriter, *http.Request, Params)
+
+	// An optional name of the route, which is reported by
+	// Params.RouteName. Names must be unique in a routing table.
+	Name string
+
+	// Optional data about the route, which is reported by
+	// Params.RouteMetadata, so that a handler may serve multiple
+	// routes differently, such as redirecting them to different
+	// places. The handlers set as HandleFunc of the routes with
+	// metadata always get Params through the request context.
+	// The map must not be modified after New is called.
+	Metadata map[string]string
 }
 
 // New returns a *TinyRouter value, which is also a http.Handler value.
+// It panics if c is invalid, such as containing bad patterns.
 func New(c Config) *TinyRouter {
-	tr := &TinyRouter{othersHandleFunc: c.OthersHandleFunc}
+	tr, err := compile(c)
+	if err != nil {
+		panic(err)
+	}
+	return tr
+}
+
+// A routeError is an error in the route at index in Config.Routes.
+type routeError struct {
+	index int
+	err   error
+}
+
+func (e *routeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *routeError) Unwrap() error {
+	return e.err
+}
+
+// compile is the same as New, except it returns the errors in c.
+// LoadConfig calls it to validate the routes the same way as New.
+func compile(c Config) (*TinyRouter, error) {
+	tr := &TinyRouter{
+		othersHandleFunc: c.OthersHandleFunc,
+		setPathValues:    c.SetPathValues,
+		precedence:       c.Precedence,
+		maxLookupSteps:   c.MaxLookupSteps,
+		noBacktracking:   c.NoBacktracking,
+		serveMuxSyntax:   c.ServeMuxSyntax,
+	}
+	if tr.maxLookupSteps <= 0 {
+		tr.maxLookupSteps = math.MaxInt
+	}
+	if tr.noBacktracking && tr.precedence != PrecedenceLeftToRight {
+		return nil, errors.New("NoBacktracking only works with PrecedenceLeftToRight")
+	}
 	if tr.othersHandleFunc == nil {
 		tr.othersHandleFunc = http.NotFound
 	}
+	if c.LookupCacheSize > 0 {
+		tr.cache = newLookupCache(c.LookupCacheSize)
+	}
 	tr.pathsByMethod = make(map[string]*[maxSegmentsInPath][]*path, 8)
-	tr.entryByMethod = make(map[string]*[maxSegmentsInPath]*segment, 8)
+	tr.remainderPathsByMethod = make(map[string]*[maxSegmentsInPath][]*path)
 
-	for _, r := range c.Routes {
-		if r.HandleFunc == nil {
-			panic("HandleFunc of a Route can't be nil")
+	names := make(map[string]bool)
+	for index, r := range c.Routes {
+		if (r.HandleFunc == nil) == (r.Handle == nil) {
+			return nil, &routeError{index, errors.New("only one of HandleFunc and Handle of a Route may be set: " + r.Pattern)}
 		}
-		rpath := parsePath(r)
-		if len(rpath.segments) > tr.maxNumTokens {
-			tr.maxNumTokens = len(rpath.segments)
+		if r.Name != "" {
+			if names[r.Name] {
+				return nil, &routeError{index, errors.New("duplicated route name [" + r.Name + "] for pattern: " + r.Pattern)}
+			}
+			names[r.Name] = true
 		}
-		if tr.entryByMethod[r.Method] == nil {
-			tr.pathsByMethod[r.Method] = &[maxSegmentsInPath][]*path{}
-			tr.entryByMethod[r.Method] = &[maxSegmentsInPath]*segment{}
+		rpaths, err := parsePattern(&r, c.ServeMuxSyntax)
+		if err != nil {
+			return nil, &routeError{index, err}
 		}
-		paths := tr.pathsByMethod[r.Method][len(rpath.segments)-1]
-		if paths == nil {
-			paths = make([]*path, 0, 4)
+		tr.routes = append(tr.routes, r)
+		for _, rpath := range rpaths {
+			rpath.index = int32(index)
+			for _, seg := range rpath.wildcards {
+				seg.sensitive = slices.Contains(c.SensitiveParams, seg.token)
+			}
+			if len(rpath.segments) > tr.maxNumTokens {
+				tr.maxNumTokens = len(rpath.segments)
+			}
+			pathsByMethod := tr.pathsByMethod
+			if rpath.remainder {
+				pathsByMethod = tr.remainderPathsByMethod
+			}
+			if pathsByMethod[r.Method] == nil {
+				pathsByMethod[r.Method] = &[maxSegmentsInPath][]*path{}
+			}
+			paths := pathsByMethod[r.Method][len(rpath.segments)-1]
+			if paths == nil {
+				paths = make([]*path, 0, 4)
+			}
+			pathsByMethod[r.Method][len(rpath.segments)-1] = append(paths, rpath)
+		}
+	}
+
+	if err := buildPathGroups(tr.pathsByMethod, &tr.tables); err != nil {
+		return nil, err
+	}
+	if err := buildPathGroups(tr.remainderPathsByMethod, &tr.remainderTables); err != nil {
+		return nil, err
+	}
+	if tr.serveMuxSyntax {
+		if err := tr.checkConflicts(); err != nil {
+			return nil, err
 		}
-		tr.pathsByMethod[r.Method][len(rpath.segments)-1] = append(paths, rpath)
 	}
+	return tr, nil
+}
 
-	for method, pathsByNumTokens := range tr.pathsByMethod {
+// buildPathGroups sorts the paths in each group (by method and number
+// of tokens) and builds the relations between the segments in them.
+// Equal paths are reported as an error in the later declared route.
+func buildPathGroups(pathsByMethod map[string]*[maxSegmentsInPath][]*path, tables *methodTables) error {
+	for method, pathsByNumTokens := range pathsByMethod {
 		for numTokens, paths := range pathsByNumTokens {
 			if paths == nil {
 				continue
@@ -409,66 +1140,68 @@ func New(c Config) *TinyRouter {
 				return comparePaths(paths[i], paths[j]) < 0
 			})
 
-			for prevPath, i, row := paths[0], 1, int32(0); i < len(paths); i++ {
-				path := paths[i]
-				if comparePaths(prevPath, path) == 0 {
-					panic(fmt.Sprintf("Equal paths are not allowed:\n   %s\n   %s", prevPath.raw, path.raw))
+			for i := 1; i < len(paths); i++ {
+				if comparePaths(paths[i-1], paths[i]) == 0 {
+					err := fmt.Errorf("Equal paths are not allowed:\n   %s\n   %s", paths[i-1].raw, paths[i].raw)
+					return &routeError{int(max(paths[i-1].index, paths[i].index)), err}
 				}
-
-				prevSeg, seg := prevPath.segments[0], path.segments[0]
-				for seg != nil {
-					prevSeg.rowIndex, seg.rowIndex = row, row+1 // for debug
-					prevSeg.nextInCol = seg
-					prevSeg, seg = prevSeg.nextInRow, seg.nextInRow
-				}
-
-				prevPath, row = path, row+1
+				paths[i].row = int32(i)
 			}
-			tr.entryByMethod[method][numTokens] = paths[0].segments[0]
 
-			buildSegmentRelations(paths[0].segments[0], nil)
+			tables.add(method)[numTokens] = newSegmentTable(paths)
+		}
+	}
+	return nil
+}
 
-			statSamePrefixBytes := func(a, b string, num *int32) {
-				for ; *num < int32(len(a)) && a[*num] == b[*num]; *num++ {
-				}
-			}
-			for col := 0; col < len(paths[0].segments); col++ {
-				for row := 0; row < len(paths); row++ {
-					seg := paths[row].segments[col]
-					if seg.startLarger != nil {
-						statSamePrefixBytes(seg.token, seg.startLarger.token, &seg.numSameBytes)
-					}
-				}
-			}
+// methods returns the sorted methods of the routes.
+func (tr *TinyRouter) methods() []string {
+	var methods []string
+	for method := range tr.pathsByMethod {
+		methods = append(methods, method)
+	}
+	for method := range tr.remainderPathsByMethod {
+		if !slices.Contains(methods, method) {
+			methods = append(methods, method)
 		}
 	}
-	return tr
+	slices.Sort(methods)
+	return methods
 }
 
 // DumpInfo is for debug purpose.
 func (tr *TinyRouter) DumpInfo() string {
 	var b strings.Builder
-	for method, pathsByNumTokens := range tr.pathsByMethod {
-		for numTokens, paths := range pathsByNumTokens {
-			if len(paths) == 0 {
+	b.WriteString("precedence: " + tr.precedence.String())
+	dumpPathGroups(&b, tr.pathsByMethod, &tr.tables, "")
+	dumpPathGroups(&b, tr.remainderPathsByMethod, &tr.remainderTables, " (remainder)")
+	return b.String()
+}
+
+func dumpPathGroups(b *strings.Builder, pathsByMethod map[string]*[maxSegmentsInPath][]*path, tables *methodTables, kind string) {
+	for method := range pathsByMethod {
+		for numTokens, t := range tables.get(method) {
+			if t == nil {
 				continue
 			}
 
-			b.WriteString(fmt.Sprintf("\nmethod %s with %d tokens:", method, numTokens+1))
-			for i, path := range paths {
-				b.WriteString(fmt.Sprint("\n   ", i, "> "))
-				for _, seg := range path.segments {
+			b.WriteString(fmt.Sprintf("\nmethod %s with %d tokens%s:", method, numTokens+1, kind))
+			for row, path := range t.paths {
+				b.WriteString(fmt.Sprint("\n   ", row, "> "))
+				for col := range path.segments {
+					i := int32(col)*t.numRows + int32(row)
+					seg := &t.segments[i]
 					b.WriteString("[")
-					if seg.wildcard() {
+					if t.wildcard(i) {
 						b.WriteString(":")
 					}
 					b.WriteString(seg.token)
 					b.WriteString(" ")
-					b.WriteString(strconv.Itoa(seg.startLarger.row()))
+					b.WriteString(strconv.Itoa(t.row(seg.startLarger)))
 					b.WriteString(" ")
-					b.WriteString(strconv.Itoa(seg.startLonger.row()))
+					b.WriteString(strconv.Itoa(t.row(seg.startLonger)))
 					b.WriteString(" ")
-					b.WriteString(strconv.Itoa(seg.startWildcard.row()))
+					b.WriteString(strconv.Itoa(t.row(seg.startWildcard)))
 					b.WriteString(" ")
 					b.WriteString(strconv.Itoa(int(seg.numSameBytes)))
 					b.WriteString("]")
@@ -476,37 +1209,186 @@ func (tr *TinyRouter) DumpInfo() string {
 			}
 		}
 	}
-	return b.String()
 }
 
 // ServeHTTP lets *TinyRouter implement http.Handler interface.
 func (tr *TinyRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	urlPath := req.URL.Path[1:]
-	if len(urlPath) > 1024 {
-		urlPath = urlPath[:1024]
-	}
-
-	entryByNumTokens := tr.entryByMethod[req.Method]
-	if entryByNumTokens == nil {
+	var tokens pathTokens
+	path := tr.lookup(req.Method, req.URL.Path[1:], &tokens)
+	if path == nil {
 		tr.othersHandleFunc(w, req)
 		return
 	}
 
-	tokens := strings.SplitN(urlPath, "/", tr.maxNumTokens)
-	entrySegment := entryByNumTokens[len(tokens)-1]
-	if entrySegment == nil {
-		tr.othersHandleFunc(w, req)
+	if path.handleParams != nil {
+		if tr.setPathValues {
+			tr.setParamsToPathValues(req, path, &tokens)
+		}
+		path.handleParams(w, req, makeParams(path, &tokens))
 		return
 	}
 
-	path := findHandlePath(tokens, entrySegment)
+	if path.numParams > 0 || len(path.metadata) > 0 {
+		req = req.WithContext(ContextWithParams(req.Context(), makeParams(path, &tokens)))
+		if tr.setPathValues {
+			tr.setParamsToPathValues(req, path, &tokens)
+		}
+	}
+	path.handle(w, req)
+}
+
+func (tr *TinyRouter) setParamsToPathValues(req *http.Request, path *path, tokens *pathTokens) {
+	for _, seg := range path.wildcards {
+		req.SetPathValue(seg.token, tokens.value(seg))
+	}
+}
+
+// Lookup returns the parameters which would be passed to the handler
+// of the route matching the request method and URL path, if there is
+// such a route. The pattern of the route is returned by Params.Pattern.
+func (tr *TinyRouter) Lookup(method, urlPath string) (Params, bool) {
+	if !strings.HasPrefix(urlPath, "/") {
+		return Params{}, false
+	}
+	var tokens pathTokens
+	path := tr.lookup(method, urlPath[1:], &tokens)
 	if path == nil {
-		tr.othersHandleFunc(w, req)
-		return
+		return Params{}, false
 	}
+	return makeParams(path, &tokens), true
+}
 
-	if path.numParams > 0 {
-		req = req.WithContext(context.WithValue(req.Context(), paramsKeyType{}, Params{path, tokens}))
+// lookup returns the path matching urlPath (without the leading slash),
+// trying the routes with the specified method and then the ones with the
+// fallback methods. The (possibly truncated) urlPath is tokenized into
+// tokens.
+func (tr *TinyRouter) lookup(method, urlPath string, tokens *pathTokens) *path {
+	if len(urlPath) > 1024 {
+		urlPath = urlPath[:1024]
 	}
-	path.handle(w, req)
+	if tr.cache != nil {
+		if path := tr.cache.get(method, urlPath, tokens); path != nil {
+			return path
+		}
+	}
+
+	budget := lookupBudget{steps: tr.maxLookupSteps, noBacktracking: tr.noBacktracking}
+	path := tr.findPath(method, urlPath, tokens, &budget)
+	for _, fallback := range tr.fallbackMethods(method) {
+		if path != nil {
+			break
+		}
+		path = tr.findPath(fallback, urlPath, tokens, &budget)
+	}
+	if budget.steps < 0 {
+		return nil
+	}
+	if path != nil && tr.cache != nil {
+		tr.cache.put(method, urlPath, path, tokens)
+	}
+	return path
+}
+
+// Used by fallbackMethods.
+var (
+	headFallbacks = []string{http.MethodGet, ""}
+	anyFallbacks  = []string{""}
+)
+
+// fallbackMethods returns the methods of the routes to try in order,
+// if no routes with method match a request. See Config.ServeMuxSyntax.
+func (tr *TinyRouter) fallbackMethods(method string) []string {
+	switch {
+	case !tr.serveMuxSyntax || method == "":
+		return nil
+	case method == http.MethodHead:
+		return headFallbacks
+	}
+	return anyFallbacks
+}
+
+// numTokens returns how many tokens request paths are split into.
+// In the syntax of http.ServeMux, one more token is needed to tell
+// whether or not a request path is longer than the longest path.
+// Otherwise, the last token holds the rest of a longer request path.
+func (tr *TinyRouter) numTokens() int {
+	if tr.serveMuxSyntax {
+		return tr.maxNumTokens + 1
+	}
+	return tr.maxNumTokens
+}
+
+// findPath returns the path matching urlPath (without the leading slash)
+// among the ones of the specified method. urlPath is tokenized into tokens.
+func (tr *TinyRouter) findPath(method, urlPath string, tokens *pathTokens, budget *lookupBudget) *path {
+	tokens.tokenize(urlPath, tr.numTokens())
+
+	if tablesByNumTokens := tr.tables.get(method); tablesByNumTokens != nil && tokens.n <= tr.maxNumTokens {
+		if table := tablesByNumTokens[tokens.n-1]; table != nil {
+			if path := tr.precedence.matchPath(tokens, table, budget); path != nil {
+				return path
+			}
+		}
+	}
+
+	if tablesByNumTokens := tr.remainderTables.get(method); tablesByNumTokens != nil {
+		for n := min(tokens.n, tr.maxNumTokens); n > 0; n-- {
+			if table := tablesByNumTokens[n-1]; table != nil {
+				// The last token holds the remaining path.
+				tokens.n = n
+				if path := tr.precedence.matchPath(tokens, table, budget); path != nil {
+					return path
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// pathTokens tokenizes a request path (without the leading slash) by
+// recording the offsets of the tokens in it, so that no allocations
+// are needed. The last token extends to the end of the path, so it
+// holds the remaining path if the path has more tokens.
+type pathTokens struct {
+	path string
+	n    int // the number of tokens
+
+	// Token i (except the last one) ends at offset ends[i].
+	ends [maxSegmentsInPath + 1]int32
+}
+
+// tokenize is like strings.SplitN(s, "/", n),
+// except the tokens are recorded in pt.
+func (pt *pathTokens) tokenize(s string, n int) {
+	pt.path, pt.n = s, 1
+	for start := 0; pt.n < n; pt.n++ {
+		i := strings.IndexByte(s[start:], '/')
+		if i < 0 {
+			break
+		}
+		pt.ends[pt.n-1] = int32(start + i)
+		start += i + 1
+	}
+}
+
+// value returns the value of parameter seg,
+// which is in the path matching the tokens.
+func (pt *pathTokens) value(seg *segment) string {
+	if seg.colIndex < 0 { // an omitted optional parameter
+		return seg.defaultValue
+	}
+	return pt.token(int(seg.colIndex))
+}
+
+// token returns token i.
+func (pt *pathTokens) token(i int) string {
+	start := int32(0)
+	if i > 0 {
+		start = pt.ends[i-1] + 1
+	}
+	if i == pt.n-1 {
+		return pt.path[start:]
+	}
+	return pt.path[start:pt.ends[i]]
 }
@@ -2,9 +2,12 @@ package tinyrouter
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -466,3 +469,373 @@ OuterMost:
 		}
 	}
 }
+
+func TestOptionalParams(t *testing.T) {
+	var matched string
+	var params Params
+	route := func(pattern string) Route {
+		return Route{
+			Method:  "GET",
+			Pattern: pattern,
+			HandleFunc: func(w http.ResponseWriter, r *http.Request) {
+				matched, params = pattern, PathParams(r)
+			},
+		}
+	}
+	router := New(Config{Routes: []Route{
+		route("/posts/:page?=1"),
+		route("/users/:name/:tab?"),
+		route("/:lang?"),
+	}})
+
+	type lookupCase struct {
+		key   string
+		value string
+		found bool
+	}
+	var requestCases = []struct {
+		urlPath string
+		pattern string
+		lookups []lookupCase
+	}{
+		{"/posts/3", "/posts/:page?=1", []lookupCase{{"page", "3", true}}},
+		{"/posts", "/posts/:page?=1", []lookupCase{{"page", "1", true}}},
+		{"/users/alice/repos", "/users/:name/:tab?", []lookupCase{{"name", "alice", true}, {"tab", "repos", true}}},
+		{"/users/alice", "/users/:name/:tab?", []lookupCase{{"name", "alice", true}, {"tab", "", false}}},
+		{"/en", "/:lang?", []lookupCase{{"lang", "en", true}}},
+		{"/", "/:lang?", []lookupCase{{"lang", "", false}}},
+	}
+
+	for _, rc := range requestCases {
+		matched, params = "", Params{}
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", rc.urlPath, nil))
+		if matched != rc.pattern {
+			t.Errorf("%s: matched pattern %q, want %q", rc.urlPath, matched, rc.pattern)
+			continue
+		}
+		for _, lc := range rc.lookups {
+			if v, found := params.Lookup(lc.key); v != lc.value || found != lc.found {
+				t.Errorf("%s: Lookup(%q) = (%q, %v), want (%q, %v)", rc.urlPath, lc.key, v, found, lc.value, lc.found)
+			}
+		}
+	}
+
+	for _, pattern := range []string{"/a/:b?/c", "/a/:b?/:c", "/a/:b?x"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("New should panic for pattern %s", pattern)
+				}
+			}()
+			New(Config{Routes: []Route{route(pattern)}})
+		}()
+	}
+}
+
+func TestEscapedTokens(t *testing.T) {
+	var matched string
+	var params Params
+	route := func(pattern string) Route {
+		return Route{
+			Method:  "POST",
+			Pattern: pattern,
+			HandleFunc: func(w http.ResponseWriter, r *http.Request) {
+				matched, params = pattern, PathParams(r)
+			},
+		}
+	}
+	router := New(Config{Routes: []Route{
+		route(`/v1/things/\:batchGet`),
+		route(`/v1/things/:id`),
+		route(`/v1/\*/\\x`),
+	}})
+
+	var requestCases = []struct {
+		urlPath string
+		pattern string
+		id      string
+	}{
+		{"/v1/things/:batchGet", `/v1/things/\:batchGet`, ""},
+		{"/v1/things/:batchDelete", `/v1/things/:id`, ":batchDelete"},
+		{"/v1/things/batchGet", `/v1/things/:id`, "batchGet"},
+		{`/v1/*/\x`, `/v1/\*/\\x`, ""},
+	}
+	for _, rc := range requestCases {
+		matched, params = "", Params{}
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", rc.urlPath, nil))
+		if matched != rc.pattern {
+			t.Errorf("%s: matched pattern %q, want %q", rc.urlPath, matched, rc.pattern)
+		} else if id := params.Value("id"); id != rc.id {
+			t.Errorf("%s: id = %q, want %q", rc.urlPath, id, rc.id)
+		}
+	}
+}
+
+func TestSetPathValues(t *testing.T) {
+	var id, page string
+	router := New(Config{
+		SetPathValues: true,
+		Routes: []Route{{
+			Method:  "GET",
+			Pattern: "/items/:id/:page?=1",
+			HandleFunc: func(w http.ResponseWriter, r *http.Request) {
+				id, page = r.PathValue("id"), r.PathValue("page")
+			},
+		}},
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items/42", nil))
+	if id != "42" || page != "1" {
+		t.Errorf("PathValue: id = %q, page = %q, want 42 and 1", id, page)
+	}
+}
+
+type voidResponseWriter struct{}
+
+func (voidResponseWriter) Header() http.Header         { return nil }
+func (voidResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (voidResponseWriter) WriteHeader(int)             {}
+
+func TestHandleAllocs(t *testing.T) {
+	var name, id string
+	router := New(Config{Routes: []Route{
+		{
+			Method:     "GET",
+			Pattern:    "/users",
+			HandleFunc: func(w http.ResponseWriter, r *http.Request) {},
+		},
+		{
+			Method:  "GET",
+			Pattern: "/users/:name/items/:id",
+			Handle: func(w http.ResponseWriter, r *http.Request, params Params) {
+				name, id = params.Value("name"), params.Value("id")
+			},
+		},
+	}})
+
+	for _, urlPath := range []string{"/users", "/users/alice/items/42"} {
+		req := httptest.NewRequest("GET", urlPath, nil)
+		if n := testing.AllocsPerRun(100, func() { router.ServeHTTP(voidResponseWriter{}, req) }); n != 0 {
+			t.Errorf("%s: %v allocations per request, want 0", urlPath, n)
+		}
+	}
+	if name != "alice" || id != "42" {
+		t.Errorf("name = %q, id = %q, want alice and 42", name, id)
+	}
+	if n := testing.AllocsPerRun(100, func() { router.Lookup("GET", "/users/alice/items/42") }); n != 0 {
+		t.Errorf("Lookup: %v allocations, want 0", n)
+	}
+
+	// The parameters are sliced from the request path, so
+	// they may be retained after the handler returns.
+	var retained []Params
+	router = New(Config{Routes: []Route{{
+		Method:  "GET",
+		Pattern: "/files/:dir/:name",
+		Handle: func(w http.ResponseWriter, r *http.Request, params Params) {
+			retained = append(retained, params)
+		},
+	}}})
+	router.ServeHTTP(voidResponseWriter{}, httptest.NewRequest("GET", "/files/docs/a.txt", nil))
+	router.ServeHTTP(voidResponseWriter{}, httptest.NewRequest("GET", "/files/src/b.go", nil))
+	if d, n := retained[0].Value("dir"), retained[0].Value("name"); d != "docs" || n != "a.txt" {
+		t.Errorf("retained params: dir = %q, name = %q, want docs and a.txt", d, n)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("New should panic if both HandleFunc and Handle are set")
+			}
+		}()
+		New(Config{Routes: []Route{{
+			Method:     "GET",
+			Pattern:    "/",
+			HandleFunc: func(w http.ResponseWriter, r *http.Request) {},
+			Handle:     func(w http.ResponseWriter, r *http.Request, params Params) {},
+		}}})
+	}()
+}
+
+func TestParamsAccessors(t *testing.T) {
+	var lens []int
+	var names [][]string
+	var pairs []string
+	var allocs float64
+	router := New(Config{Routes: []Route{{
+		Method:  "GET",
+		Pattern: "/:org/repos/:repo/:tab?/:page?=1",
+		Handle: func(w http.ResponseWriter, r *http.Request, params Params) {
+			lens = append(lens, params.Len())
+			names = append(names, params.Names())
+			pairs = pairs[:0]
+			for k, v := range params.All() {
+				pairs = append(pairs, k+"="+v)
+			}
+			allocs = testing.AllocsPerRun(10, func() {
+				for range params.All() {
+				}
+			})
+			if params.Pattern() != "/:org/repos/:repo/:tab?/:page?=1" {
+				t.Errorf("Pattern() = %q", params.Pattern())
+			}
+		},
+	}}})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/go101/repos/tinyrouter/issues/2", nil))
+	if got := strings.Join(pairs, " "); got != "org=go101 repo=tinyrouter tab=issues page=2" {
+		t.Errorf("All() yields %s", got)
+	}
+	if allocs != 0 {
+		t.Errorf("All() allocates %v times", allocs)
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/go101/repos/tinyrouter", nil))
+	if got := strings.Join(pairs, " "); got != "org=go101 repo=tinyrouter page=1" {
+		t.Errorf("All() yields %s", got)
+	}
+	if lens[0] != 4 || lens[1] != 3 {
+		t.Errorf("Len() = %v, want 4 and 3", lens)
+	}
+	if got := strings.Join(names[1], " "); got != "org repo page" {
+		t.Errorf("Names() = %s", got)
+	}
+
+	var empty Params
+	if empty.Len() != 0 || empty.Names() != nil || empty.Pattern() != "" || empty.RouteIndex() != -1 {
+		t.Errorf("zero Params should be empty")
+	}
+	for range empty.All() {
+		t.Errorf("zero Params should have no parameters")
+	}
+}
+
+func TestLongParamsValues(t *testing.T) {
+	long := strings.Repeat("x", math.MaxUint16)
+	for _, values := range [][]string{{"a", "b", "c/d"}, {long, "b", "c/d"}, {"a", long, long + "/d"}} {
+		p, err := NewServeMuxParams("/{x}/y/{z}/{rest...}", values...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Value("x") != values[0] || p.Value("z") != values[1] || p.Value("rest") != values[2] {
+			t.Errorf("the values of %.20q... are not kept", values)
+		}
+	}
+}
+
+// backtrackingRoutes returns the routes combining fixed segment "a" and
+// wildcard segments in the first n-1 columns, with a fixed segment "z"
+// in the last column. All of them are tried for "/a/a/.../a/y".
+func backtrackingRoutes(n int, handle http.HandlerFunc) []Route {
+	var routes []Route
+	for bits := 0; bits < 1<<(n-1); bits++ {
+		var b strings.Builder
+		for i := 0; i < n-1; i++ {
+			if bits&(1<<i) != 0 {
+				fmt.Fprintf(&b, "/:p%d", i)
+			} else {
+				b.WriteString("/a")
+			}
+		}
+		b.WriteString("/z")
+		routes = append(routes, Route{Method: "GET", Pattern: b.String(), HandleFunc: handle})
+	}
+	return routes
+}
+
+func TestRouteIndex(t *testing.T) {
+	h := func(http.ResponseWriter, *http.Request) {}
+	router := New(Config{Routes: []Route{
+		{Method: "GET", Pattern: "/users/:name", HandleFunc: h},
+		{Method: "POST", Pattern: "/users/:name", HandleFunc: h},
+		{Method: "GET", Pattern: "/posts/:id?", HandleFunc: h},
+	}})
+	for url, want := range map[string]int{"/users/alice": 0, "/posts/1": 2, "/posts": 2} {
+		if p, _ := router.Lookup("GET", url); p.RouteIndex() != want {
+			t.Errorf("GET %s matches route %d, want %d", url, p.RouteIndex(), want)
+		}
+	}
+	if p, _ := router.Lookup("POST", "/users/alice"); p.RouteIndex() != 1 {
+		t.Errorf("POST /users/alice matches route %d, want 1", p.RouteIndex())
+	}
+	if p, _ := NewParams("/users/:name", "alice"); p.RouteIndex() != -1 {
+		t.Errorf("NewParams returns Params with route %d, want -1", p.RouteIndex())
+	}
+}
+
+func lookupSteps(tr *TinyRouter, urlPath string) int {
+	var tokens pathTokens
+	budget := lookupBudget{steps: math.MaxInt, noBacktracking: tr.noBacktracking}
+	tr.findPath("GET", urlPath[1:], &tokens, &budget)
+	return math.MaxInt - budget.steps
+}
+
+func TestLookupBudget(t *testing.T) {
+	matched := ""
+	handle := func(w http.ResponseWriter, r *http.Request) { matched = r.URL.Path }
+	routes := backtrackingRoutes(8, handle)
+	routes = append(routes, Route{Method: "GET", Pattern: "/:q/a/a/a/a/a/a/y", HandleFunc: handle})
+	const costly = "/a/a/a/a/a/a/a/y" // only matches the last route after trying all others
+	const cheap = "/x/x/x/x/x/x/a/z"
+
+	router := New(Config{Routes: routes})
+	if steps := lookupSteps(router, costly); steps < 1<<7 {
+		t.Errorf("%d steps are spent, at least %d are expected with backtracking", steps, 1<<7)
+	}
+	if p, _ := router.Lookup("GET", costly); p.Pattern() != "/:q/a/a/a/a/a/a/y" {
+		t.Errorf("%s matched %q", costly, p.Pattern())
+	}
+
+	router = New(Config{Routes: routes, NoBacktracking: true})
+	if steps := lookupSteps(router, costly); steps > 3*8 {
+		t.Errorf("%d steps are spent, at most %d are expected without backtracking", steps, 3*8)
+	}
+	if _, found := router.Lookup("GET", costly); found {
+		t.Errorf("%s should not match without backtracking", costly)
+	}
+	if p, _ := router.Lookup("GET", cheap); p.Pattern() != "/:p0/:p1/:p2/:p3/:p4/:p5/a/z" {
+		t.Errorf("%s matched %q without backtracking", cheap, p.Pattern())
+	}
+
+	router = New(Config{Routes: routes, MaxLookupSteps: 50})
+	for urlPath, want := range map[string]string{cheap: cheap, costly: ""} {
+		matched = ""
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", urlPath, nil))
+		if matched != want {
+			t.Errorf("%s: matched = %v, want %v", urlPath, matched != "", want != "")
+		}
+	}
+
+	for _, precedence := range []Precedence{PrecedenceMostFixed, PrecedenceDeclarationOrder} {
+		router = New(Config{Routes: routes, MaxLookupSteps: 50, Precedence: precedence})
+		if _, found := router.Lookup("GET", costly); found {
+			t.Errorf("%v: %s should exhaust the budget", precedence, costly)
+		}
+	}
+}
+
+func TestMethodDispatch(t *testing.T) {
+	methods := []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "CONNECT", "TRACE", "PURGE", "PROPFIND"}
+	handle := func(w http.ResponseWriter, r *http.Request) {}
+	var routes []Route
+	for _, method := range methods {
+		routes = append(routes, Route{Method: method, Pattern: "/" + strings.ToLower(method), HandleFunc: handle})
+	}
+	routes = append(routes, Route{Method: "", Pattern: "/{any}", HandleFunc: handle})
+	router := New(Config{Routes: routes, ServeMuxSyntax: true})
+
+	for _, method := range methods {
+		own := "/" + strings.ToLower(method)
+		if p, _ := router.Lookup(method, own); p.Pattern() != own {
+			t.Errorf("%s %s matched %q", method, own, p.Pattern())
+		}
+		if p, _ := router.Lookup(method, "/x"); p.Pattern() != "/{any}" {
+			t.Errorf("%s /x matched %q, want /{any}", method, p.Pattern())
+		}
+	}
+	// Methods are case-sensitive.
+	if p, _ := router.Lookup("get", "/get"); p.Pattern() != "/{any}" {
+		t.Errorf("get /get matched %q, want /{any}", p.Pattern())
+	}
+	if p, _ := router.Lookup("UNKNOWN", "/purge"); p.Pattern() != "/{any}" {
+		t.Errorf("UNKNOWN /purge matched %q, want /{any}", p.Pattern())
+	}
+}
@@ -0,0 +1,34 @@
+package tinyroutertest
+
+import (
+	"testing"
+
+	"go101.org/tinyrouter"
+)
+
+// CheckComplexity reports an error through t.Errorf if looking up
+// a path in router may take more than maxLookupSteps steps in the
+// worst case (see tinyrouter.TinyRouter.Analyze). No checks are made
+// if maxLookupSteps is not positive. The tinyrouter command checks
+// route files the same way with "tinyrouter lint -maxsteps".
+func CheckComplexity(t testing.TB, router *tinyrouter.TinyRouter, maxLookupSteps int) {
+	t.Helper()
+	if maxLookupSteps <= 0 {
+		return
+	}
+	a := router.Analyze()
+	for _, g := range a.Groups {
+		if g.WorstCaseSteps <= maxLookupSteps {
+			continue
+		}
+		method, kind := g.Method, ""
+		if method == "" {
+			method = "*"
+		}
+		if g.Remainder {
+			kind = " (remainder)"
+		}
+		t.Errorf("%s routes with %d segments%s: up to %d lookup steps, want at most %d",
+			method, g.NumSegments, kind, g.WorstCaseSteps, maxLookupSteps)
+	}
+}
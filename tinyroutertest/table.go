@@ -0,0 +1,145 @@
+package tinyroutertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"go101.org/tinyrouter"
+	"gopkg.in/yaml.v3"
+)
+
+// A Fixture describes a request and how it should be routed.
+type Fixture struct {
+	// The request method. Blank means GET.
+	Method string `json:"method" yaml:"method"`
+
+	// The request URL, such as "/users/alice?tab=repos".
+	URL string `json:"url" yaml:"url"`
+
+	// The pattern of the route which the request should match.
+	// Blank means the request should match no routes.
+	Pattern string `json:"pattern" yaml:"pattern"`
+
+	// The expected parameters. They are not checked if Params is nil.
+	Params map[string]string `json:"params,omitempty" yaml:"params,omitempty"`
+
+	// The expected response status code, if it is not zero.
+	// The request is served by the router to check it.
+	Status int `json:"status,omitempty" yaml:"status,omitempty"`
+}
+
+// LoadFixtures decodes a JSON array of fixtures from r. For example,
+//
+//	[
+//		{"url": "/users/alice", "pattern": "/users/:name", "params": {"name": "alice"}},
+//		{"method": "POST", "url": "/users", "pattern": "/users", "status": 201},
+//		{"url": "/nonexistent", "status": 404}
+//	]
+func LoadFixtures(r io.Reader) ([]Fixture, error) {
+	var fixtures []Fixture
+	d := json.NewDecoder(r)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&fixtures); err != nil {
+		return nil, fmt.Errorf("tinyroutertest: decoding fixtures: %w", err)
+	}
+	return fixtures, nil
+}
+
+// LoadYAMLFixtures decodes a YAML sequence of fixtures from r, in the
+// same form as LoadFixtures does. Errors report the line numbers.
+// For example,
+//
+//	# The same fixtures as in the example of LoadFixtures.
+//	- {url: /users/alice, pattern: /users/:name, params: {name: alice}}
+//	- method: POST
+//	  url: /users
+//	  pattern: /users
+//	  status: 201
+//	- {url: /nonexistent, status: 404}
+func LoadYAMLFixtures(r io.Reader) ([]Fixture, error) {
+	var fixtures []Fixture
+	d := yaml.NewDecoder(r)
+	d.KnownFields(true)
+	if err := d.Decode(&fixtures); err != nil {
+		return nil, fmt.Errorf("tinyroutertest: decoding fixtures: %w", err)
+	}
+	return fixtures, nil
+}
+
+// CheckTable checks whether or not the requests described in fixtures
+// are routed by router as expected. Each mismatch is reported through
+// t.Errorf, along with what router.Lookup returns for the request.
+func CheckTable(t testing.TB, router *tinyrouter.TinyRouter, fixtures []Fixture) {
+	t.Helper()
+	for i, f := range fixtures {
+		method := f.Method
+		if method == "" {
+			method = http.MethodGet
+		}
+		u, err := url.Parse(f.URL)
+		if err != nil {
+			t.Errorf("fixture %d: bad URL: %v", i, err)
+			continue
+		}
+
+		params, found := router.Lookup(method, u.Path)
+		switch {
+		case !found && f.Pattern != "":
+			t.Errorf("fixture %d: %s %s: Lookup matched no routes, want %s", i, method, f.URL, f.Pattern)
+		case found && params.Pattern() != f.Pattern:
+			t.Errorf("fixture %d: %s %s: Lookup matched %s, want %s", i, method, f.URL, describe(params), describeExpected(f))
+		case found && f.Params != nil && !equalParams(params, f.Params):
+			t.Errorf("fixture %d: %s %s: Lookup matched %s, want %s", i, method, f.URL, describe(params), describeExpected(f))
+		}
+
+		if f.Status != 0 {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(method, f.URL, nil))
+			if w.Code != f.Status {
+				t.Errorf("fixture %d: %s %s: status %d, want %d", i, method, f.URL, w.Code, f.Status)
+			}
+		}
+	}
+}
+
+func equalParams(params tinyrouter.Params, expected map[string]string) bool {
+	if params.Len() != len(expected) {
+		return false
+	}
+	for name, value := range params.All() {
+		if v, ok := expected[name]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// describe formats params like "/users/:name {name=alice}".
+func describe(params tinyrouter.Params) string {
+	var b strings.Builder
+	b.WriteString(params.Pattern())
+	b.WriteString(" {")
+	i := 0
+	for name, value := range params.All() {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%s=%q", name, value)
+		i++
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func describeExpected(f Fixture) string {
+	if f.Params == nil {
+		return f.Pattern
+	}
+	return fmt.Sprintf("%s %v", f.Pattern, f.Params)
+}
@@ -0,0 +1,105 @@
+package tinyroutertest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"go101.org/tinyrouter"
+)
+
+func newTestRouter() *tinyrouter.TinyRouter {
+	ok := func(w http.ResponseWriter, req *http.Request) {}
+	return tinyrouter.New(tinyrouter.Config{Routes: []tinyrouter.Route{
+		{Method: "GET", Pattern: "/users/:name", HandleFunc: ok},
+		{Method: "GET", Pattern: "/users/new", HandleFunc: ok},
+		{Method: "POST", Pattern: "/users", HandleFunc: func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		}},
+		{Method: "GET", Pattern: "/posts/:page?=1", HandleFunc: ok},
+	}})
+}
+
+func TestCheckTable(t *testing.T) {
+	for _, test := range []struct {
+		filename string
+		load     func(io.Reader) ([]Fixture, error)
+	}{
+		{"testdata/fixtures.json", LoadFixtures},
+		{"testdata/fixtures.yaml", LoadYAMLFixtures},
+	} {
+		f, err := os.Open(test.filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fixtures, err := test.load(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", test.filename, err)
+		}
+		if len(fixtures) != 8 {
+			t.Errorf("%s: %d fixtures are loaded", test.filename, len(fixtures))
+		}
+		CheckTable(t, newTestRouter(), fixtures)
+	}
+}
+
+func TestLoadYAMLFixturesErrors(t *testing.T) {
+	for _, content := range []string{
+		"- url: /users\n  path: /users\n",
+		"- url: /users\n  status: ok\n",
+		"- url: /users\n- [/posts]\n",
+	} {
+		if _, err := LoadYAMLFixtures(strings.NewReader(content)); err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("LoadYAMLFixtures(%q) returns %v, want an error at line 2", content, err)
+		}
+	}
+}
+
+// errorRecorder records the errors reported by CheckTable.
+type errorRecorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *errorRecorder) Helper() {}
+
+func (r *errorRecorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestCheckTableMismatches(t *testing.T) {
+	fixtures := []Fixture{
+		{URL: "/users/alice", Pattern: "/users/new"},
+		{URL: "/users/alice", Pattern: "/users/:name", Params: map[string]string{"name": "bob"}},
+		{URL: "/nonexistent", Pattern: "/users/:name"},
+		{Method: "POST", URL: "/users", Pattern: "/users", Status: 200},
+	}
+	want := []string{
+		`fixture 0: GET /users/alice: Lookup matched /users/:name {name="alice"}, want /users/new`,
+		`fixture 1: GET /users/alice: Lookup matched /users/:name {name="alice"}, want /users/:name map[name:bob]`,
+		`fixture 2: GET /nonexistent: Lookup matched no routes, want /users/:name`,
+		`fixture 3: POST /users: status 201, want 200`,
+	}
+
+	r := &errorRecorder{TB: t}
+	CheckTable(r, newTestRouter(), fixtures)
+	if got := strings.Join(r.errors, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("CheckTable reports:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+func TestCheckComplexity(t *testing.T) {
+	router := newTestRouter()
+	CheckComplexity(t, router, 100)
+
+	r := &errorRecorder{TB: t}
+	CheckComplexity(r, router, 3)
+	want := "GET routes with 2 segments: up to 5 lookup steps, want at most 3"
+	if got := strings.Join(r.errors, "\n"); got != want {
+		t.Errorf("CheckComplexity reports:\n%s\nwant:\n%s", got, want)
+	}
+}
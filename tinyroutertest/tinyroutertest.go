@@ -0,0 +1,46 @@
+// Package tinyroutertest provides utilities for testing
+// the handlers and route tables built with TinyRouter.
+package tinyroutertest
+
+import (
+	"net/http"
+
+	"go101.org/tinyrouter"
+)
+
+// NewParams is like tinyrouter.NewParams, but it panics on errors.
+// It is useful to test the handlers set as tinyrouter.Route.Handle.
+func NewParams(pattern string, values ...string) tinyrouter.Params {
+	return must(tinyrouter.NewParams(pattern, values...))
+}
+
+// NewServeMuxParams is like tinyrouter.NewServeMuxParams,
+// but it panics on errors.
+func NewServeMuxParams(pattern string, values ...string) tinyrouter.Params {
+	return must(tinyrouter.NewServeMuxParams(pattern, values...))
+}
+
+func must(p tinyrouter.Params, err error) tinyrouter.Params {
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// WithParams returns a shallow copy of req carrying the parameters
+// of pattern, so that tinyrouter.PathParams returns them in handlers.
+// The parameters are set to values in order. WithParams panics if
+// the pattern is invalid or values don't fit it.
+func WithParams(req *http.Request, pattern string, values ...string) *http.Request {
+	return withParams(req, NewParams(pattern, values...))
+}
+
+// WithServeMuxParams is the same as WithParams, except that pattern
+// is in the syntax of http.ServeMux.
+func WithServeMuxParams(req *http.Request, pattern string, values ...string) *http.Request {
+	return withParams(req, NewServeMuxParams(pattern, values...))
+}
+
+func withParams(req *http.Request, p tinyrouter.Params) *http.Request {
+	return req.WithContext(tinyrouter.ContextWithParams(req.Context(), p))
+}
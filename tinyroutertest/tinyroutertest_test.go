@@ -0,0 +1,81 @@
+package tinyroutertest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go101.org/tinyrouter"
+)
+
+func showRepo(w http.ResponseWriter, req *http.Request) {
+	params := tinyrouter.PathParams(req)
+	fmt.Fprint(w, params.Value("owner"), "/", params.Value("repo"), " ", params.Value("tab"))
+}
+
+func TestWithParams(t *testing.T) {
+	var testCases = []struct {
+		pattern  string
+		serveMux bool
+		values   []string
+		body     string
+	}{
+		{"/:owner/:repo/:tab?=code", false, []string{"go101", "tinyrouter", "issues"}, "go101/tinyrouter issues"},
+		{"/:owner/:repo/:tab?=code", false, []string{"go101", "tinyrouter"}, "go101/tinyrouter code"},
+		{"GET /{owner}/{repo}", true, []string{"go101", "tinyrouter"}, "go101/tinyrouter "},
+		{"/{owner}/{repo}", true, []string{"go101", "tinyrouter"}, "go101/tinyrouter "},
+	}
+	for _, tc := range testCases {
+		withParams := WithParams
+		if tc.serveMux {
+			withParams = WithServeMuxParams
+		}
+		req := withParams(httptest.NewRequest("GET", "/", nil), tc.pattern, tc.values...)
+		w := httptest.NewRecorder()
+		showRepo(w, req)
+		if body, _ := io.ReadAll(w.Result().Body); string(body) != tc.body {
+			t.Errorf("%s %v: body = %q, want %q", tc.pattern, tc.values, body, tc.body)
+		}
+	}
+}
+
+func TestNewParams(t *testing.T) {
+	p := NewServeMuxParams("/files/{path...}", "a/b/c")
+	if v := p.Value("path"); v != "a/b/c" {
+		t.Errorf("path = %q, want a/b/c", v)
+	}
+
+	for _, tc := range []struct {
+		pattern  string
+		serveMux bool
+		values   []string
+	}{
+		{"/:owner/:repo", false, []string{"go101"}},
+		{"/:owner/:repo", false, []string{"go101", "tinyrouter", "x"}},
+		{"/:owner/:repo", false, []string{"go101", "a/b"}},
+		{"owner/:repo", false, []string{"go101"}},
+		{"/{owner}", false, []string{"go101"}}, // a fixed token in the default syntax
+		{"/:owner", true, []string{"go101"}},   // a fixed token in the syntax of http.ServeMux
+	} {
+		newParams, name := tinyrouter.NewParams, "NewParams"
+		if tc.serveMux {
+			newParams, name = tinyrouter.NewServeMuxParams, "NewServeMuxParams"
+		}
+		if _, err := newParams(tc.pattern, tc.values...); err == nil {
+			t.Errorf("%s(%q, %q) should fail", name, tc.pattern, tc.values)
+		}
+	}
+}
+
+func ExampleWithParams() {
+	req := httptest.NewRequest("GET", "/go101/tinyrouter", nil)
+	req = WithParams(req, "/:owner/:repo/:tab?=code", "go101", "tinyrouter")
+
+	w := httptest.NewRecorder()
+	showRepo(w, req)
+	body, _ := io.ReadAll(w.Result().Body)
+	fmt.Println(string(body))
+	// Output: go101/tinyrouter code
+}
@@ -0,0 +1,219 @@
+package tinyrouter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A WarningKind tells what a Warning is about.
+type WarningKind int
+
+const (
+	// The route is never selected for any request path,
+	// for other routes are always preferred to it.
+	WarningUnreachable WarningKind = iota
+
+	// Some request paths are matched by both of two routes.
+	WarningOverlap
+)
+
+// A Warning describes a problem found by TinyRouter.Validate.
+type Warning struct {
+	Kind    WarningKind
+	Method  string
+	Pattern string // the pattern of the route
+
+	// For WarningUnreachable, it is the pattern of the route selected
+	// for URL instead (blank if none is selected). For WarningOverlap,
+	// it is the pattern of the other overlapping route.
+	Other string
+
+	// A sample request path demonstrating the problem.
+	URL string
+}
+
+func (w Warning) String() string {
+	route := w.Pattern
+	if strings.HasPrefix(route, "/") { // not a ServeMux pattern with the method
+		method := w.Method
+		if method == "" {
+			method = "*"
+		}
+		route = method + " " + route
+	}
+	switch w.Kind {
+	case WarningUnreachable:
+		if w.Other == "" {
+			return fmt.Sprintf("%s: unreachable, %s matches no routes", route, w.URL)
+		}
+		return fmt.Sprintf("%s: unreachable, %s is routed to %s", route, w.URL, w.Other)
+	case WarningOverlap:
+		return fmt.Sprintf("%s: overlaps %s, both matching %s", route, w.Other, w.URL)
+	}
+	return fmt.Sprintf("%s: unknown warning", route)
+}
+
+// Validate finds the routes which can never be selected, and the pairs
+// of routes (with the same method) which both match some request paths.
+// The routes with optional parameters are checked per expanded pattern,
+// so a route might be reported more than once with different URLs.
+//
+// New only rejects equal paths, so Validate is useful to check whether
+// or not a route table works as intended, in particular with a Precedence
+// other than PrecedenceLeftToRight, a MaxLookupSteps limit, or
+// Config.NoBacktracking set.
+func (tr *TinyRouter) Validate() []Warning {
+	placeholder := placeholderToken(tr)
+
+	var methods []string
+	pathsByMethod := make(map[string][]*path)
+	collect := func(groups map[string]*[maxSegmentsInPath][]*path) {
+		for method, pathsByNumTokens := range groups {
+			if pathsByMethod[method] == nil {
+				methods = append(methods, method)
+			}
+			for _, paths := range pathsByNumTokens {
+				pathsByMethod[method] = append(pathsByMethod[method], paths...)
+			}
+		}
+	}
+	collect(tr.pathsByMethod)
+	collect(tr.remainderPathsByMethod)
+	sort.Strings(methods)
+
+	var unreachables, overlaps []Warning
+	for _, method := range methods {
+		paths := pathsByMethod[method]
+		sort.SliceStable(paths, func(i, j int) bool {
+			return paths[i].index < paths[j].index
+		})
+
+		for _, path := range paths {
+			if w, ok := tr.checkReachable(method, path, placeholder); !ok {
+				unreachables = append(unreachables, w)
+			}
+		}
+
+		reported := make(map[[2]int32]bool)
+		for i, x := range paths {
+			for _, y := range paths[i+1:] {
+				key := [2]int32{x.index, y.index}
+				if x.index == y.index || reported[key] {
+					continue
+				}
+				if url, ok := overlapURL(x, y, placeholder); ok {
+					reported[key] = true
+					overlaps = append(overlaps, Warning{
+						Kind:    WarningOverlap,
+						Method:  method,
+						Pattern: x.raw,
+						Other:   y.raw,
+						URL:     url,
+					})
+				}
+			}
+		}
+	}
+	return append(unreachables, overlaps...)
+}
+
+// checkReachable looks up the most general request paths matching path,
+// which use a placeholder token for each of the wildcard segments. Other
+// request paths matching path are also matched by all the routes matching
+// the general ones, so path is unreachable if it is selected for none of them.
+func (tr *TinyRouter) checkReachable(method string, path *path, placeholder string) (Warning, bool) {
+	n, maxN := len(path.segments), len(path.segments)
+	if path.remainder {
+		maxN = max(n, tr.maxNumTokens) + 1
+	}
+
+	var w Warning
+	for ; n <= maxN; n++ {
+		url := sampleURL(path, n, placeholder)
+		p, found := tr.Lookup(method, url)
+		if p.path == path {
+			return Warning{}, true
+		}
+		if w.URL == "" {
+			w = Warning{Kind: WarningUnreachable, Method: method, Pattern: path.raw, URL: url}
+			if found {
+				w.Other = p.Pattern()
+			}
+		}
+	}
+	return w, false
+}
+
+// sampleURL returns a request path with n tokens matching path.
+// The wildcard tokens are all replaced by placeholder.
+func sampleURL(path *path, n int, placeholder string) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteByte('/')
+		if i < len(path.segments) && !path.segments[i].wildcard() {
+			b.WriteString(path.segments[i].token)
+		} else {
+			b.WriteString(placeholder)
+		}
+	}
+	return b.String()
+}
+
+// overlapURL returns a request path matched by both path x and path y,
+// if there are any.
+func overlapURL(x, y *path, placeholder string) (string, bool) {
+	// Let x be the one deciding the number of tokens in the request path.
+	if x.remainder && (!y.remainder || len(y.segments) > len(x.segments)) {
+		x, y = y, x
+	}
+	numCompared := len(y.segments)
+	if y.remainder {
+		numCompared-- // the remainder segment matches the remaining tokens
+	}
+	if len(x.segments) < len(y.segments) || !y.remainder && len(x.segments) != len(y.segments) {
+		return "", false
+	}
+
+	var b strings.Builder
+	for i, seg := range x.segments {
+		token := seg.token
+		if i < numCompared && !y.segments[i].wildcard() {
+			if !seg.wildcard() && seg.token != y.segments[i].token {
+				return "", false
+			}
+			token = y.segments[i].token
+		} else if seg.wildcard() {
+			token = placeholder
+		}
+		b.WriteByte('/')
+		b.WriteString(token)
+	}
+	return b.String(), true
+}
+
+// placeholderToken returns a token which equals none of the fixed
+// segments of the routes of routers, so that it only matches wildcards.
+func placeholderToken(routers ...*TinyRouter) string {
+	fixed := make(map[string]bool)
+	for _, tr := range routers {
+		for _, groups := range []map[string]*[maxSegmentsInPath][]*path{tr.pathsByMethod, tr.remainderPathsByMethod} {
+			for _, pathsByNumTokens := range groups {
+				for _, paths := range pathsByNumTokens {
+					for _, path := range paths {
+						for _, seg := range path.segments {
+							if !seg.wildcard() {
+								fixed[seg.token] = true
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	token := "x"
+	for i := 1; fixed[token]; i++ {
+		token = fmt.Sprintf("x%d", i)
+	}
+	return token
+}
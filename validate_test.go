@@ -0,0 +1,147 @@
+package tinyrouter
+
+import (
+	"math/rand"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	handle := func(w http.ResponseWriter, r *http.Request) {}
+	route := func(method, pattern string) Route {
+		return Route{Method: method, Pattern: pattern, HandleFunc: handle}
+	}
+
+	tests := []struct {
+		config Config
+		want   []string
+	}{{
+		config: Config{Routes: []Route{
+			route("GET", "/users/:name"),
+			route("GET", "/users/new"),
+			route("GET", "/posts/:id"),
+			route("POST", "/users/new"),
+		}},
+		want: []string{
+			"GET /users/:name: overlaps /users/new, both matching /users/new",
+		},
+	}, {
+		config: Config{Precedence: PrecedenceDeclarationOrder, Routes: []Route{
+			route("GET", "/:a/:b"),
+			route("GET", "/x/y"),
+			route("GET", "/x"),
+		}},
+		want: []string{
+			"GET /x/y: unreachable, /x/y is routed to /:a/:b",
+			"GET /:a/:b: overlaps /x/y, both matching /x/y",
+		},
+	}, {
+		config: Config{Precedence: PrecedenceMostFixed, Routes: []Route{
+			route("GET", "/:a/:b"),
+			route("GET", "/x/y"),
+		}},
+		want: []string{
+			"GET /:a/:b: overlaps /x/y, both matching /x/y",
+		},
+	}, {
+		// The first route only matches the request paths with one
+		// token, which are matched by the second one, which is preferred.
+		config: Config{ServeMuxSyntax: true, Routes: []Route{
+			route("GET", "/{all...}"),
+			route("GET", "/{a}"),
+			route("GET", "/{a}/{rest...}"),
+			route("POST", "/{x}"),
+		}},
+		want: []string{
+			"GET /{all...}: unreachable, /x is routed to /{a}",
+			"GET /{all...}: overlaps /{a}, both matching /x",
+			"GET /{all...}: overlaps /{a}/{rest...}, both matching /x/x",
+		},
+	}, {
+		// The methods in the patterns are not repeated.
+		config: Config{ServeMuxSyntax: true, Routes: []Route{
+			route("", "GET /{all...}"),
+			route("", "GET /{a}"),
+			route("", "GET /{a}/{rest...}"),
+		}},
+		want: []string{
+			"GET /{all...}: unreachable, /x is routed to GET /{a}",
+			"GET /{all...}: overlaps GET /{a}, both matching /x",
+			"GET /{all...}: overlaps GET /{a}/{rest...}, both matching /x/x",
+		},
+	}, {
+		config: Config{Routes: []Route{
+			route("GET", "/a/:b?"),
+			route("GET", "/a/b/:c"),
+		}},
+		want: nil,
+	}}
+
+	for i, test := range tests {
+		var got []string
+		for _, w := range New(test.config).Validate() {
+			got = append(got, w.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("test %d: Validate returns\n\t%s\nwant\n\t%s", i, strings.Join(got, "\n\t"), strings.Join(test.want, "\n\t"))
+		}
+	}
+}
+
+// The sample URLs of overlaps are matched by both routes,
+// and the routes reported as unreachable are never selected.
+func TestValidateRandomTables(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	matches := func(router *TinyRouter, method, pattern, url string) bool {
+		tokens := strings.Split(url[1:], "/")
+		for _, groups := range []map[string]*[maxSegmentsInPath][]*path{router.pathsByMethod, router.remainderPathsByMethod} {
+			if groups[method] == nil {
+				continue
+			}
+			for _, paths := range groups[method] {
+				for _, path := range paths {
+					if _, ok := referenceMatch(path, tokens, path.remainder); ok && path.raw == pattern {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+
+	for i := 0; i < 1000; i++ {
+		router := randomRouter(rng, i, 60)
+
+		unreachable := make(map[string]bool)
+		for _, w := range router.Validate() {
+			switch w.Kind {
+			case WarningOverlap:
+				if !matches(router, w.Method, w.Pattern, w.URL) || !matches(router, w.Method, w.Other, w.URL) {
+					t.Fatalf("%s: %s is not matched by both routes:\n%s", w, w.URL, router.DumpInfo())
+				}
+			case WarningUnreachable:
+				unreachable[w.Method+" "+w.Pattern+" "+strings.Repeat("/", strings.Count(w.URL, "/"))] = true
+			}
+		}
+
+		for j := 0; j < 20; j++ {
+			for _, url := range fuzzURLs(randomBytes(rng, rng.Intn(6))) {
+				p, found := router.Lookup("GET", url)
+				if !found {
+					continue
+				}
+				method := "GET"
+				if router.pathsByMethod[method] == nil && router.remainderPathsByMethod[method] == nil ||
+					!matches(router, method, p.Pattern(), url) {
+					method = ""
+				}
+				key := method + " " + p.Pattern() + " " + strings.Repeat("/", len(p.path.segments))
+				if unreachable[key] {
+					t.Fatalf("%s is reported as unreachable, but %s is routed to it:\n%s", p.Pattern(), url, router.DumpInfo())
+				}
+			}
+		}
+	}
+}